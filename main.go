@@ -1,31 +1,66 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"time"
 
+	"github.com/vbvictor/ccv/pkg/check"
 	"github.com/vbvictor/ccv/pkg/complexity"
+	"github.com/vbvictor/ccv/pkg/config"
+	"github.com/vbvictor/ccv/pkg/coverage"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/vbvictor/ccv/pkg/git"
 	"github.com/vbvictor/ccv/pkg/plot"
 	"github.com/vbvictor/ccv/pkg/process"
+	"github.com/vbvictor/ccv/pkg/review"
+	"github.com/vbvictor/ccv/pkg/snapshot"
 )
 
 // File to store the output graph
 var (
 	outputFile                   = ""
 	ComplexityFuncThreshold uint = 5
+	plotEngine                   = complexity.LizardXML
+	analyzeEngine                = complexity.Lizard
+	churnEngine                  = complexity.Lizard
+	functionChurn                = false
+	coverageFile                 = ""
+	coverageFormat               = coverage.Auto
+	reportUnmatched              = false
+	riskLevelsFile               = ""
+	configRiskLevels             = false
+	configFile                   = ""
+	configProfile                = ""
+	snapshotFile                 = "ccv-snapshot.json"
+	snapshotFunctions            = true
+	diffFormat                   = snapshot.Tabular
+	hotspotLevel                 = ""
+	junitFile                    = ""
+	reviewFormat                 = review.Markdown
+	seriesFile                   = "churn_series.html"
+	timeout                      = time.Duration(0)
 )
 
+// Flags of churn command that need git history and can not be applied to saved git log
+var gitOnlyFlags = []string{
+	"since", "until", "rev", "first-parent", "no-merges", "pathspec", "cache", "cache-dir",
+	"authors", "half-life", "bucket", "by-function", "ignore-whitespace", "author", "exclude-author",
+}
+
 func main() {
 	cmdPlot := &cobra.Command{
 		Use:   "plot [flags] <churn_file> <complexity_file>",
 		Short: "Compare code complexity and churn metrics",
 		Args:  cobra.ExactArgs(2),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			return plot.ValidateRiskThresholds()
+			return validatePlotOptions()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			churnFile := args[0]
@@ -42,70 +77,102 @@ func main() {
 			}
 			defer cf.Close()
 
-			churns, err := complexity.ReadChurn(cf)
+			report, err := complexity.ReadChurnReport(cf)
 			if err != nil {
 				return fmt.Errorf("error reading churn data: %w", err)
 			}
 
+			if err := process.ValidateChurnData(report); err != nil {
+				return err
+			}
+			churns := report.Files
+
 			// Read complexity data
-			xf, err := os.Open(complexityFile)
+			analyzer, err := complexity.GetAnalyzer(plotEngine)
 			if err != nil {
-				return fmt.Errorf("Error opening complexity file: %w\n", err)
+				return err
 			}
-			defer xf.Close()
 
-			lizard, err := complexity.ReadLizardXML(xf)
+			files, err := analyzer.Analyze(complexityFile)
 			if err != nil {
 				return fmt.Errorf("Error reading complexity data: %w\n", err)
 			}
 
-			files, err := complexity.ParseLizard(lizard)
-			if err != nil {
-				return fmt.Errorf("Error parsing complexity data: %w\n", err)
+			// Prepare plot data
+			if err := attachCoverage(files); err != nil {
+				return err
 			}
 
-			// Prepare plot data
 			files = process.ApplyFilters(files, process.ComplexityFilter{MinComplexity: ComplexityFuncThreshold}.Filter)
 			entries := process.PreparePlotData(files, churns)
 
-			// Generate output
-			switch plot.OutputFormat {
-			case plot.Tabular:
-				if err := plot.CreateTableChart(entries, os.Stdout); err != nil {
-					return fmt.Errorf("error creating tabular chart: %w\n", err)
-				}
-			case plot.CSV:
-				if err := plot.CreateTableChart(entries, os.Stdout); err != nil {
-					return fmt.Errorf("error creating csv chart: %w\n", err)
-				}
-			case plot.Scatter:
-				if err := plot.CreateScatterChart(entries, &plot.NoopMapper{}, outputFile); err != nil {
-					return fmt.Errorf("error creating scatter chart: %w\n", err)
-				}
-			default:
-				return fmt.Errorf("Invalid output format: %s\n", plot.OutputFormat)
+			if reportUnmatched {
+				process.PrintUnmatched(files, churns, os.Stderr)
 			}
 
-			if process.Verbose && plot.OutputFormat == plot.Scatter {
-				fmt.Printf("Chart generated: %s\n", outputFile)
-			}
-
-			return nil
+			return writeChart(entries)
 		},
 	}
 
 	flags := cmdPlot.PersistentFlags()
 	flags.StringVarP(&outputFile, "output", "o", "complexity_churn.html", "Output file path")
 	flags.BoolVarP(&process.Verbose, "verbose", "v", false, "Enable verbose output")
-	flags.StringVarP(&process.Plot, "plot-type", "t", "commits", "Specify OY plot type: [commits, changes]")
+	flags.StringVarP(&process.Plot, "plot-type", "t", "commits", fmt.Sprintf("Specify OY plot type: %v", process.PlotTypes))
 	flags.UintVarP(&ComplexityFuncThreshold, "min-complexity", "m", 5, "Complexity threshold to delete functions with low complexity from the plot")
 	flags.StringVarP(&plot.OutputFormat, "output-format", "f", "tabular", "Specify output format: [tabular, csv, scatter]")
+	flags.StringVar(&coverageFile, "coverage", "", "Coverage report to use as a third risk dimension")
+	flags.StringVar(&coverageFormat, "coverage-format", coverage.Auto, fmt.Sprintf("Format of coverage report: %v", coverage.Formats))
+	flags.StringVar(&plot.ColorBy, "color-by", plot.ColorNone, fmt.Sprintf("Colour of points in scatter chart: %v", plot.ColorTypes))
+	flags.StringVar(&process.AggregateOpts.Type, "aggregate", process.Mean, fmt.Sprintf("Aggregation of function complexities into file complexity: %v", process.Aggregations))
+	flags.UintVar(&process.AggregateOpts.Threshold, "aggregate-threshold", 15, "Complexity threshold of functions counted by above-threshold aggregation")
+	flags.StringVar(&plot.RiskOpts.Formula, "risk-formula", plot.FormulaProduct, fmt.Sprintf("Formula of risk score: %v", plot.RiskFormulas))
+	flags.StringVar(&plot.RiskOpts.Expression, "risk-expr", "", "Risk score expression over complexity, churn and coverage for expr formula. For example complexity^2 * log(churn + 1)")
+	flags.StringVar(&plot.RiskOpts.Normalization, "normalize", plot.NormalizeNone, fmt.Sprintf("Normalization of complexity and churn before computing risk score: %v", plot.Normalizations))
+	flags.Float64Var(&plot.RiskOpts.ComplexityWeight, "complexity-weight", 1, "Weight of complexity in geomean formula")
+	flags.Float64Var(&plot.RiskOpts.ChurnWeight, "churn-weight", 1, "Weight of churn in geomean formula")
+	flags.StringVar(&riskLevelsFile, "risk-levels", "", "YAML or JSON file with named risk levels, their colours and min/max bounds")
+	flags.IntVar(&plot.WidthPx, "width", 1200, "Width of scatter chart in px")
+	flags.IntVar(&plot.HeightPx, "height", 800, "Height of scatter chart in px")
+	flags.StringVarP(&plotEngine, "engine", "e", complexity.LizardXML, fmt.Sprintf("Complexity engine used to read complexity_file: %v", complexity.Engines()))
+	flags.StringVar(&process.PathOpts.RepoRoot, "repo-root", "", "Repository root used to make absolute complexity paths relative")
+	flags.StringSliceVar(&process.PathOpts.StripPrefixes, "strip-prefix", []string{}, "Path prefixes removed before matching complexity and churn files")
+	flags.BoolVar(&process.PathOpts.SuffixMatch, "suffix-match", false, "Match files by the longest common path suffix if paths differ")
+	flags.BoolVar(&reportUnmatched, "report-unmatched", false, "List files that have only complexity or only churn data")
 
 	cmdChurn := &cobra.Command{
 		Use:   "churn <repository>",
 		Short: "Get churn metrics of a repository",
-		Args:  cobra.ExactArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			// Saved git log replaces repository
+			if git.ChurnOpts.FromLog != "" {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if git.ChurnOpts.SortBy == git.Weighted && git.ChurnOpts.HalfLife <= 0 {
+				return fmt.Errorf("--sort %s requires --half-life", git.Weighted)
+			}
+
+			if git.ChurnOpts.FromLog != "" {
+				for _, name := range gitOnlyFlags {
+					if cmd.Flags().Changed(name) {
+						return fmt.Errorf("--%s can not be used with --from-log, apply it when git log is saved", name)
+					}
+				}
+
+				// Repository is optional with saved log, filters read its files such as .mailmap
+				repoPath := ""
+				if len(args) > 0 {
+					var err error
+					if repoPath, err = filepath.Abs(args[0]); err != nil {
+						return fmt.Errorf("error getting absolute path: %w", err)
+					}
+				}
+
+				return git.PrintRepoStats(cmd.Context(), repoPath)
+			}
+
 			repoPath, err := filepath.Abs(args[0])
 			if err != nil {
 				return fmt.Errorf("error getting absolute path: %w", err)
@@ -115,28 +182,967 @@ func main() {
 				fmt.Printf("Processing repository: %s\n", repoPath)
 			}
 
-			return git.PrintRepoStats(repoPath)
+			if functionChurn && git.ChurnOpts.Bucket != "" {
+				return fmt.Errorf("--bucket can not be used with --by-function")
+			}
+
+			if functionChurn {
+				analyzer, err := complexity.GetAnalyzer(churnEngine)
+				if err != nil {
+					return err
+				}
+
+				files, err := analyzer.Analyze(repoPath)
+				if err != nil {
+					return fmt.Errorf("error getting function ranges: %w", err)
+				}
+
+				process.PathOpts.RepoRoot = repoPath
+				files = process.NormalizeFiles(files, process.PathOpts)
+
+				return git.PrintFunctionStats(cmd.Context(), repoPath, files)
+			}
+
+			if git.ChurnOpts.Bucket != "" {
+				return writeSeries(cmd.Context(), repoPath)
+			}
+
+			return git.PrintRepoStats(cmd.Context(), repoPath)
 		},
 	}
 
 	flags = cmdChurn.PersistentFlags()
 	flags.IntVar(&git.ChurnOpts.CommitCount, "commits", 0, "Number of commits to analyze")
-	flags.StringVar(&git.ChurnOpts.SortBy, "sort", "changes", fmt.Sprintf("Sort by: %s, %s, %s, %s", git.Changes, git.Additions, git.Deletions, git.Commits))
+	flags.StringVar(&git.ChurnOpts.SortBy, "sort", "changes", fmt.Sprintf("Sort by: %s, %s, %s, %s, %s", git.Changes, git.Additions, git.Deletions, git.Commits, git.Weighted))
 	flags.IntVar(&git.ChurnOpts.Top, "top", 10, "Number of top files to display")
 	flags.BoolVar(&process.Verbose, "verbose", false, "Show detailed progress")
 	flags.StringVar(&git.ChurnOpts.ExcludePath, "exclude", "", "Exclude files matching regex pattern")
 	flags.StringVar(&git.ChurnOpts.Extensions, "ext", "", "Only include files with extensions in comma-separated list. For example h,hpp,c,cpp")
 	flags.Var(&git.ChurnOpts.Since, "since", "Start date for analysis (YYYY-MM-DD)")
 	flags.Var(&git.ChurnOpts.Until, "until", "End date for analysis (YYYY-MM-DD)")
-	flags.StringVar(&git.ChurnOpts.OutputFormat, "format", git.Tabular, fmt.Sprintf("Output format %v", git.OutputFormats))
+	flags.StringSliceVar(&git.ChurnOpts.Revisions, "rev", []string{}, "Revisions or revision ranges to analyze instead of HEAD, can be repeated. For example v1.2..v1.3 or main..feature")
+	flags.BoolVar(&git.ChurnOpts.FirstParent, "first-parent", false, "Follow only the first parent of merge commits and count merges as changes against it")
+	flags.BoolVar(&git.ChurnOpts.NoMerges, "no-merges", false, "Skip merge commits")
+	flags.StringVar(&git.ChurnOpts.Path, "path", "", "Subdirectory of repository to analyze")
+	flags.StringSliceVar(&git.ChurnOpts.Pathspecs, "pathspec", []string{}, "Git pathspecs relative to --path limiting analyzed files, can be repeated. For example '*.go' or ':!vendor'")
+	flags.BoolVar(&git.ChurnOpts.Cache, "cache", false, "Keep numstat of commits in .git/ccv, so later runs read only new commits")
+	flags.StringVar(&git.ChurnOpts.CacheDir, "cache-dir", "", "Directory of commit cache, enables --cache")
+	flags.StringVar(&git.ChurnOpts.IgnoreRevsFile, "ignore-revs-file", "", "Skip commits listed in file in format of .git-blame-ignore-revs")
+	flags.IntVar(&git.ChurnOpts.MaxFilesPerCommit, "max-files-per-commit", 0, "Skip commits that change more files, e.g. reformatting or vendoring. 0 disables the limit")
+	flags.BoolVar(&git.ChurnOpts.IgnoreWhitespace, "ignore-whitespace", false, "Ignore whitespace when counting changed lines")
+	flags.StringVar(&git.ChurnOpts.Author, "author", "", "Only analyze commits which author or co-author matches regex, authors are normalized by .mailmap")
+	flags.StringVar(&git.ChurnOpts.ExcludeAuthor, "exclude-author", "", "Skip commits which author matches regex. For example '\\[bot\\]'")
+	flags.StringVar(&git.ChurnOpts.FromLog, "from-log", "", "Read output of 'git log --pretty=format:%H --numstat' from file or '-' for stdin instead of running git")
+	flags.StringVar(&git.ChurnOpts.OutputFormat, "format", git.Tabular, fmt.Sprintf("Output format %v, with --bucket %v", git.OutputFormats, git.SeriesFormats))
+	flags.BoolVar(&git.ChurnOpts.Authors, "authors", false, "Collect commit authors and show ownership of files")
+	flags.Float64Var(&git.ChurnOpts.HalfLife, "half-life", 0, "Half-life in days of commit changes in weighted churn, 0 disables weighting")
+	flags.StringVar(&git.ChurnOpts.Bucket, "bucket", "", fmt.Sprintf("Split churn of files into series by period: %v", git.Buckets))
+	flags.StringVarP(&seriesFile, "output", "o", "churn_series.html", "Output file path of series chart")
+	flags.BoolVar(&plot.Stacked, "stacked", false, "Draw series chart as stacked areas instead of lines")
+	flags.IntVar(&plot.WidthPx, "width", 1200, "Width of series chart in px")
+	flags.IntVar(&plot.HeightPx, "height", 800, "Height of series chart in px")
+	flags.BoolVar(&functionChurn, "by-function", false, "Attribute changed lines to functions instead of files")
+	flags.StringVarP(&churnEngine, "engine", "e", complexity.Lizard, fmt.Sprintf("Complexity engine used to find functions with --by-function: %v", complexity.Engines()))
 
 	cmdChurn.Flag("since").DefValue = "none"
 	cmdChurn.Flag("until").DefValue = "none"
 
-	rootCmd := &cobra.Command{Use: "ccv"}
-	rootCmd.AddCommand(cmdPlot, cmdChurn)
+	cmdCoupling := &cobra.Command{
+		Use:   "coupling <repository>",
+		Short: "Get files of a repository that change together",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, err := filepath.Abs(args[0])
+			if err != nil {
+				return fmt.Errorf("error getting absolute path: %w", err)
+			}
+
+			if process.Verbose {
+				fmt.Printf("Processing repository: %s\n", repoPath)
+			}
+
+			return git.PrintRepoCoupling(cmd.Context(), repoPath)
+		},
+	}
+
+	flags = cmdCoupling.PersistentFlags()
+	flags.IntVar(&git.ChurnOpts.CommitCount, "commits", 0, "Number of commits to analyze")
+	flags.IntVar(&git.ChurnOpts.Top, "top", 10, "Number of top file pairs to display")
+	flags.BoolVar(&process.Verbose, "verbose", false, "Show detailed progress")
+	flags.StringVar(&git.ChurnOpts.ExcludePath, "exclude", "", "Exclude files matching regex pattern")
+	flags.StringVar(&git.ChurnOpts.Extensions, "ext", "", "Only include files with extensions in comma-separated list. For example h,hpp,c,cpp")
+	flags.Var(&git.ChurnOpts.Since, "since", "Start date for analysis (YYYY-MM-DD)")
+	flags.Var(&git.ChurnOpts.Until, "until", "End date for analysis (YYYY-MM-DD)")
+	flags.StringSliceVar(&git.ChurnOpts.Revisions, "rev", []string{}, "Revisions or revision ranges to analyze instead of HEAD, can be repeated. For example v1.2..v1.3 or main..feature")
+	flags.BoolVar(&git.ChurnOpts.FirstParent, "first-parent", false, "Follow only the first parent of merge commits and count merges as changes against it")
+	flags.BoolVar(&git.ChurnOpts.NoMerges, "no-merges", false, "Skip merge commits")
+	flags.StringVar(&git.ChurnOpts.Path, "path", "", "Subdirectory of repository to analyze")
+	flags.StringSliceVar(&git.ChurnOpts.Pathspecs, "pathspec", []string{}, "Git pathspecs relative to --path limiting analyzed files, can be repeated. For example '*.go' or ':!vendor'")
+	flags.BoolVar(&git.ChurnOpts.Cache, "cache", false, "Keep numstat of commits in .git/ccv, so later runs read only new commits")
+	flags.StringVar(&git.ChurnOpts.CacheDir, "cache-dir", "", "Directory of commit cache, enables --cache")
+	flags.StringVar(&git.ChurnOpts.IgnoreRevsFile, "ignore-revs-file", "", "Skip commits listed in file in format of .git-blame-ignore-revs")
+	flags.IntVar(&git.ChurnOpts.MaxFilesPerCommit, "max-files-per-commit", 0, "Skip commits that change more files, e.g. reformatting or vendoring. 0 disables the limit")
+	flags.BoolVar(&git.ChurnOpts.IgnoreWhitespace, "ignore-whitespace", false, "Ignore whitespace when counting changed lines")
+	flags.StringVar(&git.ChurnOpts.Author, "author", "", "Only analyze commits which author or co-author matches regex, authors are normalized by .mailmap")
+	flags.StringVar(&git.ChurnOpts.ExcludeAuthor, "exclude-author", "", "Skip commits which author matches regex. For example '\\[bot\\]'")
+	flags.StringVar(&git.ChurnOpts.OutputFormat, "format", git.Tabular, fmt.Sprintf("Output format %v", git.OutputFormats))
+	flags.UintVar(&git.CouplingOpts.MinSharedCommits, "min-shared", 5, "Minimal number of commits in which both files changed")
+	flags.UintVar(&git.CouplingOpts.MinRevisions, "min-revs", 5, "Minimal number of commits of each file in pair")
+	flags.IntVar(&git.CouplingOpts.MaxChangesetSize, "max-changeset", 30, "Skip commits that modify more files, 0 disables the limit")
+
+	cmdCoupling.Flag("since").DefValue = "none"
+	cmdCoupling.Flag("until").DefValue = "none"
+
+	cmdAnalyze := &cobra.Command{
+		Use:   "analyze [flags] <repository>",
+		Short: "Compute churn and complexity of a repository and compare them",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validatePlotOptions()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, err := filepath.Abs(args[0])
+			if err != nil {
+				return fmt.Errorf("error getting absolute path: %w", err)
+			}
+
+			if process.Verbose {
+				fmt.Printf("Analyzing repository: %s\n", repoPath)
+			}
+
+			_, _, entries, err := analyzeRepository(cmd.Context(), repoPath)
+			if err != nil {
+				return err
+			}
+
+			return writeChart(entries)
+		},
+	}
+
+	flags = cmdAnalyze.PersistentFlags()
+	addAnalyzeFlags(flags)
+	flags.StringVarP(&outputFile, "output", "o", "complexity_churn.html", "Output file path")
+	flags.StringVarP(&plot.OutputFormat, "output-format", "f", "tabular", "Specify output format: [tabular, csv, scatter]")
+	flags.StringVar(&plot.ColorBy, "color-by", plot.ColorNone, fmt.Sprintf("Colour of points in scatter chart: %v", plot.ColorTypes))
+	flags.IntVar(&plot.WidthPx, "width", 1200, "Width of scatter chart in px")
+	flags.IntVar(&plot.HeightPx, "height", 800, "Height of scatter chart in px")
+
+	cmdAnalyze.Flag("since").DefValue = "none"
+	cmdAnalyze.Flag("until").DefValue = "none"
+
+	cmdSnapshot := &cobra.Command{
+		Use:   "snapshot [flags] <repository>",
+		Short: "Save churn, complexity and risk of a repository to compare them later",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validatePlotOptions()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, err := filepath.Abs(args[0])
+			if err != nil {
+				return fmt.Errorf("error getting absolute path: %w", err)
+			}
+
+			if process.Verbose {
+				fmt.Printf("Taking snapshot of repository: %s\n", repoPath)
+			}
+
+			files, _, entries, err := analyzeRepository(cmd.Context(), repoPath)
+			if err != nil {
+				return err
+			}
+
+			snap, err := newSnapshot(cmd.Context(), repoPath, files, entries, snapshotFunctions)
+			if err != nil {
+				return err
+			}
+
+			if err := snapshot.Save(snap, snapshotFile); err != nil {
+				return fmt.Errorf("error saving snapshot: %w", err)
+			}
+
+			if process.Verbose {
+				fmt.Printf("Snapshot saved: %s\n", snapshotFile)
+			}
+
+			return nil
+		},
+	}
+
+	flags = cmdSnapshot.PersistentFlags()
+	addAnalyzeFlags(flags)
+	flags.StringVarP(&snapshotFile, "output", "o", "ccv-snapshot.json", "Output snapshot file path")
+	flags.BoolVar(&snapshotFunctions, "functions", true, "Include churn and risk of functions, requires reading patches of all commits")
+
+	cmdSnapshot.Flag("since").DefValue = "none"
+	cmdSnapshot.Flag("until").DefValue = "none"
+
+	cmdDiff := &cobra.Command{
+		Use:   "diff [flags] <old_snapshot> <new_snapshot>",
+		Short: "Compare two snapshots and report regressions and hotspots",
+		Args:  cobra.ExactArgs(2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return loadRiskLevels()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldSnap, err := snapshot.Load(args[0])
+			if err != nil {
+				return fmt.Errorf("error reading snapshot: %w", err)
+			}
+
+			newSnap, err := snapshot.Load(args[1])
+			if err != nil {
+				return fmt.Errorf("error reading snapshot: %w", err)
+			}
+
+			if oldSnap.RiskFormula != newSnap.RiskFormula {
+				fmt.Fprintf(os.Stderr, "Warning: risk scores are computed differently: %q and %q\n", oldSnap.RiskFormula, newSnap.RiskFormula)
+			}
+
+			// Scores of snapshots are categorized by their own levels unless levels are given explicitly
+			levels := plot.RiskLevels
+			if riskLevelsFile == "" && !configRiskLevels {
+				levels = newSnap.Levels(levels)
+			}
+
+			threshold, err := plot.LevelThreshold(levels, hotspotLevel)
+			if err != nil {
+				return err
+			}
+
+			opts := snapshot.CompareOpts
+			opts.HotspotThreshold = threshold
+
+			return snapshot.PrintDiff(snapshot.Compare(oldSnap, newSnap, opts), os.Stdout, diffFormat)
+		},
+	}
+
+	flags = cmdDiff.PersistentFlags()
+	flags.StringVar(&diffFormat, "format", snapshot.Tabular, fmt.Sprintf("Output format %v", snapshot.OutputFormats))
+	flags.Float64Var(&snapshot.CompareOpts.ComplexityDelta, "complexity-delta", 0, "Report complexity that rose by more than delta")
+	flags.Float64Var(&snapshot.CompareOpts.ChurnDelta, "churn-delta", 10, "Report churn that rose by more than delta")
+	flags.Float64Var(&snapshot.CompareOpts.RiskDelta, "risk-delta", 10, "Report risk score that rose by more than delta")
+	flags.StringVar(&hotspotLevel, "hotspot-level", "", "Lowest risk level of hotspots, the highest level by default")
+	flags.StringVar(&riskLevelsFile, "risk-levels", "", "YAML or JSON file with named risk levels, their colours and min/max bounds")
+
+	cmdCheck := &cobra.Command{
+		Use:   "check [flags] <repository>",
+		Short: "Evaluate quality rules on churn and complexity of a repository, exit with error if any fails",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := validatePlotOptions(); err != nil {
+				return err
+			}
+
+			if err := validateLevelNames(check.Opts.ForbiddenLevels); err != nil {
+				return err
+			}
+
+			threshold, err := hotspotThreshold()
+			if err != nil {
+				return err
+			}
+			check.Opts.HotspotThreshold = threshold
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			repoPath, err := filepath.Abs(args[0])
+			if err != nil {
+				return fmt.Errorf("error getting absolute path: %w", err)
+			}
+
+			data := check.Data{}
+			if check.Opts.Baseline != "" {
+				if data.Baseline, err = snapshot.Load(check.Opts.Baseline); err != nil {
+					return fmt.Errorf("error reading baseline snapshot: %w", err)
+				}
+			}
+
+			files, _, entries, err := analyzeRepository(cmd.Context(), repoPath)
+			if err != nil {
+				return err
+			}
+
+			if data.Current, err = newSnapshot(cmd.Context(), repoPath, files, entries, false); err != nil {
+				return err
+			}
+			data.Files = files
+
+			results, err := check.Run(data, check.Opts)
+			if err != nil {
+				return err
+			}
+
+			check.PrintSummary(results, os.Stdout)
+
+			if junitFile != "" {
+				if err := writeJUnit(results); err != nil {
+					return fmt.Errorf("error writing junit report: %w", err)
+				}
+			}
+
+			if failed := check.Failed(results); failed > 0 {
+				return fmt.Errorf("%d of %d rules failed", failed, len(results))
+			}
+
+			return nil
+		},
+	}
+
+	flags = cmdCheck.PersistentFlags()
+	addAnalyzeFlags(flags)
+	flags.UintVar(&check.Opts.MaxFunctionComplexity, "max-function-complexity", 0, "Fail if any function has greater complexity, 0 disables the rule")
+	flags.StringSliceVar(&check.Opts.ForbiddenLevels, "forbid-level", []string{}, "Fail if any file is in risk level, can be repeated. For example \"Critical Risk\"")
+	flags.StringVar(&check.Opts.Baseline, "baseline", "", "Snapshot to compare with, fail if any file became a hotspot")
+	flags.StringVar(&hotspotLevel, "hotspot-level", "", "Lowest risk level of hotspots, the highest level by default")
+	flags.StringVar(&junitFile, "junit", "", "Write JUnit XML report to file")
+
+	cmdCheck.Flag("since").DefValue = "none"
+	cmdCheck.Flag("until").DefValue = "none"
+
+	cmdReview := &cobra.Command{
+		Use:   "review [flags] <base>..<head> [repository]",
+		Short: "List files and functions changed in a revision range with their complexity, churn and risk",
+		Args:  cobra.RangeArgs(1, 2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := validatePlotOptions(); err != nil {
+				return err
+			}
+
+			threshold, err := hotspotThreshold()
+			if err != nil {
+				return err
+			}
+			review.Opts.HotspotThreshold = threshold
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			repoPath := "."
+			if len(args) > 1 {
+				repoPath = args[1]
+			}
+
+			repoPath, err := filepath.Abs(repoPath)
+			if err != nil {
+				return fmt.Errorf("error getting absolute path: %w", err)
+			}
+
+			report, err := reviewRange(cmd.Context(), repoPath, args[0])
+			if err != nil {
+				return err
+			}
+
+			return review.PrintReport(report, os.Stdout, reviewFormat)
+		},
+	}
+
+	flags = cmdReview.PersistentFlags()
+	flags.BoolVarP(&process.Verbose, "verbose", "v", false, "Enable verbose output")
+	flags.StringVarP(&process.Plot, "plot-type", "t", "commits", fmt.Sprintf("Specify OY plot type: %v", process.PlotTypes))
+	flags.StringVarP(&analyzeEngine, "engine", "e", complexity.Lizard, fmt.Sprintf("Complexity engine used to analyze changed files: %v", complexity.Engines()))
+	flags.StringVar(&complexity.ComplexityOpts.Extensions, "lang", "", "Only analyze complexity of languages in comma-separated list. For example cpp,python")
+	flags.IntVar(&complexity.ComplexityOpts.Threads, "threads", 1, "Number of threads used to compute complexity")
+	flags.StringVar(&process.AggregateOpts.Type, "aggregate", process.Mean, fmt.Sprintf("Aggregation of function complexities into file complexity: %v", process.Aggregations))
+	flags.UintVar(&process.AggregateOpts.Threshold, "aggregate-threshold", 15, "Complexity threshold of functions counted by above-threshold aggregation")
+	flags.StringVar(&plot.RiskOpts.Formula, "risk-formula", plot.FormulaProduct, fmt.Sprintf("Formula of risk score: %v", plot.RiskFormulas))
+	flags.StringVar(&plot.RiskOpts.Expression, "risk-expr", "", "Risk score expression over complexity, churn and coverage for expr formula. For example complexity^2 * log(churn + 1)")
+	flags.StringVar(&plot.RiskOpts.Normalization, "normalize", plot.NormalizeNone, fmt.Sprintf("Normalization of complexity and churn before computing risk score: %v", plot.Normalizations))
+	flags.Float64Var(&plot.RiskOpts.ComplexityWeight, "complexity-weight", 1, "Weight of complexity in geomean formula")
+	flags.Float64Var(&plot.RiskOpts.ChurnWeight, "churn-weight", 1, "Weight of churn in geomean formula")
+	flags.StringVar(&riskLevelsFile, "risk-levels", "", "YAML or JSON file with named risk levels, their colours and min/max bounds")
+	flags.StringVar(&hotspotLevel, "hotspot-level", "", "Lowest risk level of high-risk code, the highest level by default")
+	flags.Float64Var(&review.Opts.ComplexityDelta, "complexity-delta", 0, "Flag changes that raise complexity by more than delta")
+	flags.IntVar(&git.ChurnOpts.CommitCount, "commits", 0, "Number of commits before base to analyze")
+	flags.StringVar(&git.ChurnOpts.ExcludePath, "exclude", "", "Exclude files matching regex pattern")
+	flags.StringVar(&git.ChurnOpts.Extensions, "ext", "", "Only include files with extensions in comma-separated list. For example h,hpp,c,cpp")
+	flags.Var(&git.ChurnOpts.Since, "since", "Start date for analysis (YYYY-MM-DD)")
+	flags.Var(&git.ChurnOpts.Until, "until", "End date for analysis (YYYY-MM-DD)")
+	flags.Float64Var(&git.ChurnOpts.HalfLife, "half-life", 0, "Half-life in days of commit changes in weighted churn, 0 disables weighting")
+	flags.StringVar(&reviewFormat, "format", review.Markdown, fmt.Sprintf("Output format %v", review.OutputFormats))
+
+	cmdReview.Flag("since").DefValue = "none"
+	cmdReview.Flag("until").DefValue = "none"
 
-	if err := rootCmd.Execute(); err != nil {
+	rootCmd := &cobra.Command{
+		Use: "ccv",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfig(cmd, args); err != nil {
+				return err
+			}
+
+			if process.Verbose {
+				git.ChurnOpts.Progress = os.Stderr
+				complexity.ComplexityOpts.Warnings = os.Stderr
+			}
+
+			if timeout > 0 {
+				ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+				cmd.SetContext(ctx)
+				cobra.OnFinalize(cancel)
+			}
+
+			return nil
+		},
+	}
+	rootCmd.AddCommand(cmdPlot, cmdChurn, cmdCoupling, cmdAnalyze, cmdSnapshot, cmdDiff, cmdCheck, cmdReview)
+
+	flags = rootCmd.PersistentFlags()
+	flags.StringVar(&configFile, "config", "", fmt.Sprintf("Config file, by default %s is searched in repository root", config.FileName))
+	flags.StringVar(&configProfile, "profile", "", "Profile of config file to apply over common options")
+	flags.DurationVar(&timeout, "timeout", 0, "Stop reading git history after timeout, for example 30s or 5m. No timeout by default")
+
+	// Interrupt kills running git commands instead of leaving them behind
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		stop()
 		os.Exit(1)
 	}
 }
+
+// addAnalyzeFlags registers flags of commands that join churn and complexity of repository
+func addAnalyzeFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&process.Verbose, "verbose", "v", false, "Enable verbose output")
+	flags.StringVarP(&process.Plot, "plot-type", "t", "commits", fmt.Sprintf("Specify OY plot type: %v", process.PlotTypes))
+	flags.UintVarP(&ComplexityFuncThreshold, "min-complexity", "m", 5, "Complexity threshold to delete functions with low complexity from the plot")
+	flags.StringVar(&coverageFile, "coverage", "", "Coverage report to use as a third risk dimension")
+	flags.StringVar(&coverageFormat, "coverage-format", coverage.Auto, fmt.Sprintf("Format of coverage report: %v", coverage.Formats))
+	flags.StringVar(&process.AggregateOpts.Type, "aggregate", process.Mean, fmt.Sprintf("Aggregation of function complexities into file complexity: %v", process.Aggregations))
+	flags.UintVar(&process.AggregateOpts.Threshold, "aggregate-threshold", 15, "Complexity threshold of functions counted by above-threshold aggregation")
+	flags.StringVar(&plot.RiskOpts.Formula, "risk-formula", plot.FormulaProduct, fmt.Sprintf("Formula of risk score: %v", plot.RiskFormulas))
+	flags.StringVar(&plot.RiskOpts.Expression, "risk-expr", "", "Risk score expression over complexity, churn and coverage for expr formula. For example complexity^2 * log(churn + 1)")
+	flags.StringVar(&plot.RiskOpts.Normalization, "normalize", plot.NormalizeNone, fmt.Sprintf("Normalization of complexity and churn before computing risk score: %v", plot.Normalizations))
+	flags.Float64Var(&plot.RiskOpts.ComplexityWeight, "complexity-weight", 1, "Weight of complexity in geomean formula")
+	flags.Float64Var(&plot.RiskOpts.ChurnWeight, "churn-weight", 1, "Weight of churn in geomean formula")
+	flags.StringVar(&riskLevelsFile, "risk-levels", "", "YAML or JSON file with named risk levels, their colours and min/max bounds")
+	flags.IntVar(&git.ChurnOpts.CommitCount, "commits", 0, "Number of commits to analyze")
+	flags.StringVar(&git.ChurnOpts.ExcludePath, "exclude", "", "Exclude files matching regex pattern")
+	flags.StringVar(&git.ChurnOpts.Extensions, "ext", "", "Only include files with extensions in comma-separated list. For example h,hpp,c,cpp")
+	flags.Var(&git.ChurnOpts.Since, "since", "Start date for analysis (YYYY-MM-DD)")
+	flags.Var(&git.ChurnOpts.Until, "until", "End date for analysis (YYYY-MM-DD)")
+	flags.StringSliceVar(&git.ChurnOpts.Revisions, "rev", []string{}, "Revisions or revision ranges to analyze instead of HEAD, can be repeated. For example v1.2..v1.3 or main..feature")
+	flags.BoolVar(&git.ChurnOpts.FirstParent, "first-parent", false, "Follow only the first parent of merge commits and count merges as changes against it")
+	flags.BoolVar(&git.ChurnOpts.NoMerges, "no-merges", false, "Skip merge commits")
+	flags.StringVar(&git.ChurnOpts.Path, "path", "", "Subdirectory of repository to analyze")
+	flags.StringSliceVar(&git.ChurnOpts.Pathspecs, "pathspec", []string{}, "Git pathspecs relative to --path limiting analyzed files, can be repeated. For example '*.go' or ':!vendor'")
+	flags.BoolVar(&git.ChurnOpts.Cache, "cache", false, "Keep numstat of commits in .git/ccv, so later runs read only new commits")
+	flags.StringVar(&git.ChurnOpts.CacheDir, "cache-dir", "", "Directory of commit cache, enables --cache")
+	flags.StringVar(&git.ChurnOpts.IgnoreRevsFile, "ignore-revs-file", "", "Skip commits listed in file in format of .git-blame-ignore-revs")
+	flags.IntVar(&git.ChurnOpts.MaxFilesPerCommit, "max-files-per-commit", 0, "Skip commits that change more files, e.g. reformatting or vendoring. 0 disables the limit")
+	flags.BoolVar(&git.ChurnOpts.IgnoreWhitespace, "ignore-whitespace", false, "Ignore whitespace when counting changed lines")
+	flags.StringVar(&git.ChurnOpts.Author, "author", "", "Only analyze commits which author or co-author matches regex, authors are normalized by .mailmap")
+	flags.StringVar(&git.ChurnOpts.ExcludeAuthor, "exclude-author", "", "Skip commits which author matches regex. For example '\\[bot\\]'")
+	flags.Float64Var(&git.ChurnOpts.HalfLife, "half-life", 0, "Half-life in days of commit changes in weighted churn, 0 disables weighting")
+	flags.StringVar(&complexity.ComplexityOpts.Extensions, "lang", "", "Only analyze complexity of languages in comma-separated list. For example cpp,python")
+	flags.IntVar(&complexity.ComplexityOpts.Threads, "threads", 1, "Number of threads used to compute complexity")
+	flags.StringVarP(&analyzeEngine, "engine", "e", complexity.Lizard, fmt.Sprintf("Complexity engine used to analyze repository: %v", complexity.Engines()))
+	flags.StringSliceVar(&process.PathOpts.StripPrefixes, "strip-prefix", []string{}, "Path prefixes removed before matching complexity and churn files")
+	flags.BoolVar(&process.PathOpts.SuffixMatch, "suffix-match", false, "Match files by the longest common path suffix if paths differ")
+	flags.BoolVar(&reportUnmatched, "report-unmatched", false, "List files that have only complexity or only churn data")
+}
+
+// validatePlotOptions checks options of commands that compute risk of files
+func validatePlotOptions() error {
+	if err := process.ValidatePlotType(); err != nil {
+		return err
+	}
+
+	if git.ChurnOpts.HalfLife < 0 {
+		return fmt.Errorf("half-life must not be negative")
+	}
+
+	if err := process.ValidateAggregation(); err != nil {
+		return err
+	}
+	plot.ComplexityAggregation = process.AggregateOpts.String()
+
+	if err := plot.ValidateRiskOptions(plot.RiskOpts); err != nil {
+		return err
+	}
+
+	return loadRiskLevels()
+}
+
+// validateWeightedChurn checks that weighted churn is computed from git history when it is plotted
+func validateWeightedChurn() error {
+	if process.Plot == process.Weighted && git.ChurnOpts.HalfLife <= 0 {
+		return fmt.Errorf("plot type %s requires --half-life", process.Weighted)
+	}
+
+	return nil
+}
+
+// analyzeRepository computes churn and complexity of repository and joins them.
+// Returns complexity of all functions, so rules see functions below min-complexity too,
+// churn of all files and entries joined from functions above min-complexity.
+func analyzeRepository(ctx context.Context, repoPath string) (complexity.FilesStat, []*complexity.ChurnChunk, []plot.ScatterEntry, error) {
+	if err := validateWeightedChurn(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// All files are needed to join them with complexity data
+	churnOpts := git.ChurnOpts
+	churnOpts.Top = -1
+
+	churns, err := git.ReadChurn(ctx, repoPath, churnOpts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting churn metrics: %w", err)
+	}
+
+	analyzer, err := complexity.GetAnalyzer(analyzeEngine)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	files, err := analyzer.Analyze(repoPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting complexity metrics: %w", err)
+	}
+
+	process.PathOpts.RepoRoot = repoPath
+	files = process.NormalizeFiles(files, process.PathOpts)
+
+	if err := attachCoverage(files); err != nil {
+		return nil, nil, nil, err
+	}
+
+	filtered := process.ApplyFilters(files, process.ComplexityFilter{MinComplexity: ComplexityFuncThreshold}.Filter)
+	entries := process.PreparePlotData(filtered, churns)
+
+	if reportUnmatched {
+		process.PrintUnmatched(filtered, churns, os.Stderr)
+	}
+
+	return files, churns, entries, nil
+}
+
+// newSnapshot computes risk of joined entries, churn and risk of functions above min-complexity
+// are computed only if requested
+func newSnapshot(ctx context.Context, repoPath string, files complexity.FilesStat, entries []plot.ScatterEntry, withFunctions bool) (*snapshot.Snapshot, error) {
+	scorer, err := plot.NewRiskScorer(plot.RiskOpts, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := git.HeadCommit(ctx, repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting current commit: %w", err)
+	}
+
+	snap := &snapshot.Snapshot{
+		Version:     snapshot.Version,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		Repository:  filepath.Base(repoPath),
+		Commit:      commit,
+		RiskFormula: scorer.Describe(coverageFile != ""),
+		Risk:        snapshot.NewRiskSettings(plot.RiskOpts, plot.RiskLevels),
+		Files:       make([]snapshot.FileEntry, 0, len(entries)),
+		Functions:   make([]snapshot.FunctionEntry, 0),
+	}
+
+	for _, entry := range entries {
+		risk := scorer.Score(entry.ScatterData)
+		file := snapshot.FileEntry{
+			Path:       entry.File,
+			Complexity: entry.Complexity,
+			Churn:      entry.Churn,
+			Risk:       math.Round(risk*100) / 100,
+			RiskLevel:  plot.RiskLevelOf(risk),
+		}
+		if entry.HasCoverage {
+			file.Coverage = &entry.Coverage
+		}
+		snap.Files = append(snap.Files, file)
+	}
+
+	if withFunctions {
+		files = process.ApplyFilters(files, process.ComplexityFilter{MinComplexity: ComplexityFuncThreshold}.Filter)
+		functions, err := functionEntries(ctx, repoPath, files)
+		if err != nil {
+			return nil, err
+		}
+		snap.Functions = functions
+	}
+
+	snap.Sort()
+
+	return snap, nil
+}
+
+// functionEntries computes churn and risk of functions, risk is scored relative to other functions
+func functionEntries(ctx context.Context, repoPath string, files complexity.FilesStat) ([]snapshot.FunctionEntry, error) {
+	churnOpts := git.ChurnOpts
+	churnOpts.Top = -1
+
+	churns, err := git.ReadFunctionChurn(ctx, repoPath, churnOpts, files)
+	if err != nil {
+		return nil, fmt.Errorf("error getting function churn metrics: %w", err)
+	}
+
+	churnOf := make(map[string]*complexity.FunctionChurn, len(churns))
+	for _, fn := range churns {
+		churnOf[fmt.Sprintf("%s:%s:%d", fn.File, snapshot.FunctionName(fn.Package, fn.Name), fn.Line)] = fn
+	}
+
+	result := make([]snapshot.FunctionEntry, 0)
+	data := make([]plot.ScatterEntry, 0)
+
+	for _, file := range files {
+		for _, fn := range file.Functions {
+			entry := snapshot.FunctionEntry{
+				Path:       file.Path,
+				Name:       snapshot.FunctionName(fn.Package, fn.Name),
+				Line:       fn.Line,
+				Complexity: fn.Compexity,
+			}
+
+			if churn, exists := churnOf[fmt.Sprintf("%s:%s:%d", file.Path, entry.Name, fn.Line)]; exists {
+				entry.Churn = process.ChurnValue(&churn.ChurnChunk)
+			}
+
+			result = append(result, entry)
+			data = append(data, plot.ScatterEntry{
+				File: file.Path,
+				ScatterData: plot.ScatterData{
+					Complexity:  float64(fn.Compexity),
+					Churn:       entry.Churn,
+					Coverage:    fn.Coverage,
+					HasCoverage: fn.HasCoverage,
+				},
+			})
+		}
+	}
+
+	scorer, err := plot.NewRiskScorer(plot.RiskOpts, data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result {
+		result[i].Risk = math.Round(scorer.Score(data[i].ScatterData)*100) / 100
+	}
+
+	return result, nil
+}
+
+// hotspotThreshold returns minimal risk score of hotspots from hotspot-level flag
+func hotspotThreshold() (float64, error) {
+	return plot.LevelThreshold(plot.RiskLevels, hotspotLevel)
+}
+
+// reviewRange analyzes files changed in revision range, churn is taken from history before base
+func reviewRange(ctx context.Context, repoPath, revRange string) (*review.Report, error) {
+	if err := validateWeightedChurn(); err != nil {
+		return nil, err
+	}
+
+	base, head, err := git.ParseRange(ctx, repoPath, revRange)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := git.ReadDiff(ctx, repoPath, base, head, git.ChurnOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting changed files: %w", err)
+	}
+
+	if process.Verbose {
+		fmt.Printf("Reviewing %d changed files between %s and %s\n", len(changes), base, head)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "ccv-review-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	basePaths, headPaths := make([]string, 0), make([]string, 0)
+	for _, change := range changes {
+		if change.OldPath != "" {
+			basePaths = append(basePaths, change.OldPath)
+		}
+		if change.Path != "" {
+			headPaths = append(headPaths, change.Path)
+		}
+	}
+
+	baseFiles, err := analyzeRevision(ctx, repoPath, base, basePaths, filepath.Join(tmpDir, "base"))
+	if err != nil {
+		return nil, err
+	}
+
+	headFiles, err := analyzeRevision(ctx, repoPath, head, headPaths, filepath.Join(tmpDir, "head"))
+	if err != nil {
+		return nil, err
+	}
+
+	churnOpts := git.ChurnOpts
+	churnOpts.Top = -1
+	churnOpts.Revisions = []string{base}
+
+	churns, err := git.ReadChurn(ctx, repoPath, churnOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting churn metrics: %w", err)
+	}
+
+	functionChurn, err := git.ReadFunctionChurn(ctx, repoPath, churnOpts, baseFiles)
+	if err != nil {
+		return nil, fmt.Errorf("error getting function churn metrics: %w", err)
+	}
+
+	return review.Build(review.Input{
+		Base:          base,
+		Head:          head,
+		Changes:       changes,
+		BaseFiles:     baseFiles,
+		HeadFiles:     headFiles,
+		Churns:        churns,
+		FunctionChurn: functionChurn,
+	}, review.Opts)
+}
+
+// analyzeRevision exports files at revision to dir and computes their complexity
+func analyzeRevision(ctx context.Context, repoPath, rev string, paths []string, dir string) (complexity.FilesStat, error) {
+	if len(paths) == 0 {
+		return complexity.FilesStat{}, nil
+	}
+
+	if err := git.ExportFiles(ctx, repoPath, rev, paths, dir); err != nil {
+		return nil, fmt.Errorf("error exporting files: %w", err)
+	}
+
+	analyzer, err := complexity.GetAnalyzer(analyzeEngine)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := analyzer.Analyze(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error getting complexity metrics: %w", err)
+	}
+
+	return process.NormalizeFiles(files, process.PathOptions{RepoRoot: dir}), nil
+}
+
+// validateLevelNames checks that risk levels with names exist
+func validateLevelNames(names []string) error {
+	for _, name := range names {
+		found := false
+		for _, level := range plot.RiskLevels {
+			found = found || level.Name == name
+		}
+
+		if !found {
+			return fmt.Errorf("unknown risk level %q", name)
+		}
+	}
+
+	return nil
+}
+
+// writeJUnit saves results of check to file given by junit flag
+func writeJUnit(results []check.Result) error {
+	f, err := os.Create(junitFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return check.WriteJUnit(results, f)
+}
+
+// writeChart prints or renders entries in format chosen by output-format flag
+func writeChart(entries []plot.ScatterEntry) error {
+	switch plot.OutputFormat {
+	case plot.Tabular:
+		if err := plot.CreateTableChart(entries, os.Stdout); err != nil {
+			return fmt.Errorf("error creating tabular chart: %w\n", err)
+		}
+	case plot.CSV:
+		if err := plot.CreateCSVChart(entries, os.Stdout); err != nil {
+			return fmt.Errorf("error creating csv chart: %w\n", err)
+		}
+	case plot.Scatter:
+		mapper, err := chartMapper(entries)
+		if err != nil {
+			return err
+		}
+
+		if err := plot.CreateScatterChart(entries, mapper, outputFile); err != nil {
+			return fmt.Errorf("error creating scatter chart: %w\n", err)
+		}
+	default:
+		return fmt.Errorf("Invalid output format: %s\n", plot.OutputFormat)
+	}
+
+	if process.Verbose && plot.OutputFormat == plot.Scatter {
+		fmt.Printf("Chart generated: %s\n", outputFile)
+	}
+
+	return nil
+}
+
+// writeSeries prints churn series of repository or draws them as line chart
+func writeSeries(ctx context.Context, repoPath string) error {
+	if git.ChurnOpts.OutputFormat != git.Chart {
+		return git.PrintRepoSeries(ctx, repoPath)
+	}
+
+	series, err := git.ReadChurnSeries(ctx, repoPath, git.ChurnOpts)
+	if err != nil {
+		return fmt.Errorf("error getting churn series: %w", err)
+	}
+
+	lines := make([]plot.LineSeries, 0, len(series.Files))
+	for _, file := range series.Files {
+		lines = append(lines, plot.LineSeries{Name: file.File, Values: file.Values(git.ChurnOpts.SortBy)})
+	}
+
+	if err := plot.CreateLineChart(series.Periods, lines, git.ChurnOpts.SortBy, seriesFile); err != nil {
+		return fmt.Errorf("error creating series chart: %w", err)
+	}
+
+	if process.Verbose {
+		fmt.Printf("Chart generated: %s\n", seriesFile)
+	}
+
+	return nil
+}
+
+// chartMapper chooses colours of scatter chart points by color-by flag
+func chartMapper(entries []plot.ScatterEntry) (plot.EntryMapper, error) {
+	switch plot.ColorBy {
+	case plot.ColorNone:
+		return &plot.NoopMapper{}, nil
+	case plot.ColorRisk:
+		scorer, err := plot.NewRiskScorer(plot.RiskOpts, entries)
+		if err != nil {
+			return nil, err
+		}
+
+		return plot.NewRisksMapper(scorer), nil
+	case plot.ColorCoverage:
+		return &plot.CoverageMapper{}, nil
+	default:
+		return nil, fmt.Errorf("Invalid point colour: %s, use one of the following: %v\n", plot.ColorBy, plot.ColorTypes)
+	}
+}
+
+// attachCoverage reads coverage report given by coverage flag and attaches it to files
+func attachCoverage(files complexity.FilesStat) error {
+	if coverageFile == "" {
+		return nil
+	}
+
+	report, err := coverage.ReadReport(coverageFile, coverageFormat)
+	if err != nil {
+		return fmt.Errorf("error reading coverage report: %w", err)
+	}
+
+	process.AttachCoverage(files, report)
+
+	return nil
+}
+
+// applyConfig sets options of command from config file, flags given in command line take precedence
+func applyConfig(cmd *cobra.Command, args []string) error {
+	path := configFile
+	if path == "" {
+		found, err := config.Find(configSearchStart(cmd, args))
+		if err != nil {
+			return fmt.Errorf("error searching config file: %w", err)
+		}
+		path = found
+	}
+
+	if path == "" {
+		if configProfile != "" {
+			return fmt.Errorf("profile %q is given, but no %s is found", configProfile, config.FileName)
+		}
+		return nil
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	options, err := cfg.Resolve(configProfile)
+	if err != nil {
+		return fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+
+	if process.Verbose {
+		fmt.Printf("Using config file: %s\n", path)
+	}
+
+	known := func(name string) bool {
+		for _, command := range cmd.Root().Commands() {
+			if command.Flags().Lookup(name) != nil || command.PersistentFlags().Lookup(name) != nil {
+				return true
+			}
+		}
+		return false
+	}
+
+	if err := config.Apply(cmd.Flags(), options, known); err != nil {
+		return fmt.Errorf("error applying config file %s: %w", path, err)
+	}
+
+	// Levels listed inline replace defaults, levels given as path are read by loadRiskLevels
+	_, configRiskLevels = options["risk-levels"].([]any)
+
+	return nil
+}
+
+// configSearchStart returns directory of repository that command works on, config file is searched from it
+func configSearchStart(cmd *cobra.Command, args []string) string {
+	start := "."
+	switch cmd.Name() {
+	case "review":
+		// First argument is revision range, repository is optional
+		if len(args) > 1 {
+			start = args[1]
+		}
+	case "diff":
+		// Snapshots are usually stored in repository they describe
+		if len(args) > 1 {
+			start = filepath.Dir(args[1])
+		}
+	default:
+		if len(args) > 0 {
+			start = args[0]
+		}
+	}
+
+	if info, err := os.Stat(start); err != nil || !info.IsDir() {
+		return "."
+	}
+	return start
+}
+
+// loadRiskLevels replaces default risk levels with levels from risk-levels file,
+// levels of neither file nor config are the defaults of risk formula
+func loadRiskLevels() error {
+	if riskLevelsFile == "" {
+		if !configRiskLevels {
+			plot.RiskLevels = plot.DefaultRiskLevels(plot.RiskOpts)
+		}
+		return plot.ValidateRiskLevels(plot.RiskLevels)
+	}
+
+	levels, err := plot.LoadRiskLevels(riskLevelsFile)
+	if err != nil {
+		return fmt.Errorf("error reading risk levels: %w", err)
+	}
+	plot.RiskLevels = levels
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package check
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vbvictor/ccv/pkg/complexity"
+	"github.com/vbvictor/ccv/pkg/snapshot"
+)
+
+// Names of rules used in reports
+const (
+	RuleMaxFunctionComplexity = "max-function-complexity"
+	RuleForbiddenRiskLevels   = "forbidden-risk-levels"
+	RuleNoNewHotspots         = "no-new-hotspots"
+)
+
+type Options struct {
+	// Maximal allowed complexity of functions, 0 disables the rule
+	MaxFunctionComplexity uint
+	// Risk levels files must not fall into
+	ForbiddenLevels []string
+	// Snapshot to compare with, empty disables no-new-hotspots rule
+	Baseline string
+	// Files with risk score not less than threshold are hotspots
+	HotspotThreshold float64
+}
+
+var Opts = Options{
+	MaxFunctionComplexity: 0,
+	ForbiddenLevels:       []string{},
+	Baseline:              "",
+	HotspotThreshold:      0,
+}
+
+// Data is analysis result that rules are evaluated against
+type Data struct {
+	Files    complexity.FilesStat
+	Current  *snapshot.Snapshot
+	Baseline *snapshot.Snapshot
+}
+
+type Violation struct {
+	Path     string
+	Function string
+	Line     uint
+	Message  string
+}
+
+func (v Violation) String() string {
+	location := v.Path
+	if v.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, v.Line)
+	}
+	if v.Function != "" {
+		location += " " + v.Function
+	}
+
+	return location + ": " + v.Message
+}
+
+type Result struct {
+	Rule        string
+	Description string
+	Violations  []Violation
+}
+
+func (r Result) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+// Run evaluates rules enabled by options, baseline is required by no-new-hotspots rule
+func Run(data Data, opts Options) ([]Result, error) {
+	results := make([]Result, 0)
+
+	if opts.MaxFunctionComplexity > 0 {
+		results = append(results, MaxFunctionComplexity(data.Files, opts.MaxFunctionComplexity))
+	}
+
+	if len(opts.ForbiddenLevels) > 0 {
+		results = append(results, ForbiddenRiskLevels(data.Current, opts.ForbiddenLevels))
+	}
+
+	if opts.Baseline != "" {
+		if data.Baseline == nil {
+			return nil, fmt.Errorf("baseline snapshot is not loaded")
+		}
+		results = append(results, NoNewHotspots(data.Baseline, data.Current, opts.HotspotThreshold))
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no rules are enabled")
+	}
+
+	return results, nil
+}
+
+func MaxFunctionComplexity(files complexity.FilesStat, limit uint) Result {
+	result := Result{
+		Rule:        RuleMaxFunctionComplexity,
+		Description: fmt.Sprintf("No function has complexity above %d", limit),
+		Violations:  make([]Violation, 0),
+	}
+
+	for _, file := range files {
+		for _, fn := range file.Functions {
+			if fn.Compexity > limit {
+				result.Violations = append(result.Violations, Violation{
+					Path:     file.Path,
+					Function: snapshot.FunctionName(fn.Package, fn.Name),
+					Line:     fn.Line,
+					Message:  fmt.Sprintf("complexity %d is above %d", fn.Compexity, limit),
+				})
+			}
+		}
+	}
+
+	sortViolations(result.Violations)
+
+	return result
+}
+
+func ForbiddenRiskLevels(current *snapshot.Snapshot, levels []string) Result {
+	result := Result{
+		Rule:        RuleForbiddenRiskLevels,
+		Description: fmt.Sprintf("No file has risk level %v", levels),
+		Violations:  make([]Violation, 0),
+	}
+
+	forbidden := make(map[string]bool, len(levels))
+	for _, level := range levels {
+		forbidden[level] = true
+	}
+
+	for _, file := range current.Files {
+		if forbidden[file.RiskLevel] {
+			result.Violations = append(result.Violations, Violation{
+				Path:    file.Path,
+				Message: fmt.Sprintf("risk score %.2f is in %s level", file.Risk, file.RiskLevel),
+			})
+		}
+	}
+
+	sortViolations(result.Violations)
+
+	return result
+}
+
+func NoNewHotspots(baseline, current *snapshot.Snapshot, threshold float64) Result {
+	result := Result{
+		Rule:        RuleNoNewHotspots,
+		Description: fmt.Sprintf("No new file has risk score of at least %.2f compared with baseline", threshold),
+		Violations:  make([]Violation, 0),
+	}
+
+	diff := snapshot.Compare(baseline, current, snapshot.CompareOptions{HotspotThreshold: threshold})
+	for _, hotspot := range diff.NewHotspots {
+		result.Violations = append(result.Violations, Violation{
+			Path:    hotspot.Path,
+			Message: fmt.Sprintf("became a hotspot with risk score %.2f (%s)", hotspot.Risk, hotspot.RiskLevel),
+		})
+	}
+
+	sortViolations(result.Violations)
+
+	return result
+}
+
+func sortViolations(violations []Violation) {
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Path != violations[j].Path {
+			return violations[i].Path < violations[j].Path
+		}
+		return violations[i].Line < violations[j].Line
+	})
+}
+
+// Failed counts results with violations
+func Failed(results []Result) int {
+	failed := 0
+	for _, result := range results {
+		if !result.Passed() {
+			failed++
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,208 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbvictor/ccv/pkg/complexity"
+	"github.com/vbvictor/ccv/pkg/snapshot"
+)
+
+func TestRun(t *testing.T) {
+	handle := complexity.FunctionStat{Package: []string{"Server"}, Name: "Handle", Line: 12, Compexity: 25}
+	helper := complexity.FunctionStat{Name: "helper", Line: 40, Compexity: 4}
+	server := &complexity.FileStat{Path: "server.go", Functions: []complexity.FunctionStat{handle, helper}}
+
+	snap := func(files ...snapshot.FileEntry) *snapshot.Snapshot {
+		return &snapshot.Snapshot{Files: files}
+	}
+	critical := snapshot.FileEntry{Path: "server.go", Risk: 120, RiskLevel: "Critical Risk"}
+	low := snapshot.FileEntry{Path: "util.go", Risk: 40, RiskLevel: "Low Risk"}
+	veryLow := snapshot.FileEntry{Path: "util.go", Risk: 10, RiskLevel: "Very Low Risk"}
+
+	complexityRule := func(limit uint, violations ...Violation) Result {
+		return Result{
+			Rule:        RuleMaxFunctionComplexity,
+			Description: fmt.Sprintf("No function has complexity above %d", limit),
+			Violations:  append([]Violation{}, violations...),
+		}
+	}
+	levelsRule := func(violations ...Violation) Result {
+		return Result{
+			Rule:        RuleForbiddenRiskLevels,
+			Description: "No file has risk level [Critical Risk]",
+			Violations:  append([]Violation{}, violations...),
+		}
+	}
+	hotspotsRule := func(threshold float64, violations ...Violation) Result {
+		return Result{
+			Rule:        RuleNoNewHotspots,
+			Description: fmt.Sprintf("No new file has risk score of at least %.2f compared with baseline", threshold),
+			Violations:  append([]Violation{}, violations...),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		data    Data
+		opts    Options
+		results []Result
+		err     string
+	}{
+		{
+			name: "function above max complexity",
+			data: Data{Files: complexity.FilesStat{server}},
+			opts: Options{MaxFunctionComplexity: 20},
+			results: []Result{complexityRule(20,
+				Violation{Path: "server.go", Function: "Server::Handle", Line: 12, Message: "complexity 25 is above 20"})},
+		},
+		{
+			name:    "function exactly at max complexity",
+			data:    Data{Files: complexity.FilesStat{server}},
+			opts:    Options{MaxFunctionComplexity: 25},
+			results: []Result{complexityRule(25)},
+		},
+		{
+			// Files are not filtered by min-complexity, so low limits see every function
+			name: "every function above low max complexity",
+			data: Data{Files: complexity.FilesStat{server}},
+			opts: Options{MaxFunctionComplexity: 3},
+			results: []Result{complexityRule(3,
+				Violation{Path: "server.go", Function: "Server::Handle", Line: 12, Message: "complexity 25 is above 3"},
+				Violation{Path: "server.go", Function: "helper", Line: 40, Message: "complexity 4 is above 3"})},
+		},
+		{
+			name:    "file without functions",
+			data:    Data{Files: complexity.FilesStat{{Path: "empty.go"}}},
+			opts:    Options{MaxFunctionComplexity: 1},
+			results: []Result{complexityRule(1)},
+		},
+		{
+			name: "file in forbidden level",
+			data: Data{Current: snap(critical, low)},
+			opts: Options{ForbiddenLevels: []string{"Critical Risk"}},
+			results: []Result{levelsRule(
+				Violation{Path: "server.go", Message: "risk score 120.00 is in Critical Risk level"})},
+		},
+		{
+			name:    "no file in forbidden level",
+			data:    Data{Current: snap(low)},
+			opts:    Options{ForbiddenLevels: []string{"Critical Risk"}},
+			results: []Result{levelsRule()},
+		},
+		{
+			name: "file became hotspot",
+			data: Data{Current: snap(low), Baseline: snap(veryLow)},
+			opts: Options{Baseline: "baseline.json", HotspotThreshold: 35},
+			results: []Result{hotspotsRule(35,
+				Violation{Path: "util.go", Message: "became a hotspot with risk score 40.00 (Low Risk)"})},
+		},
+		{
+			name: "risk exactly at hotspot threshold",
+			data: Data{Current: snap(low), Baseline: snap(veryLow)},
+			opts: Options{Baseline: "baseline.json", HotspotThreshold: 40},
+			results: []Result{hotspotsRule(40,
+				Violation{Path: "util.go", Message: "became a hotspot with risk score 40.00 (Low Risk)"})},
+		},
+		{
+			name:    "hotspot in baseline already",
+			data:    Data{Current: snap(critical), Baseline: snap(snapshot.FileEntry{Path: "server.go", Risk: 80, RiskLevel: "Critical Risk"})},
+			opts:    Options{Baseline: "baseline.json", HotspotThreshold: 35},
+			results: []Result{hotspotsRule(35)},
+		},
+		{
+			name:    "hotspot deleted since baseline",
+			data:    Data{Current: snap(), Baseline: snap(critical)},
+			opts:    Options{Baseline: "baseline.json", HotspotThreshold: 35},
+			results: []Result{hotspotsRule(35)},
+		},
+		{
+			name: "new file is hotspot",
+			data: Data{Current: snap(critical), Baseline: snap()},
+			opts: Options{Baseline: "baseline.json", HotspotThreshold: 35},
+			results: []Result{hotspotsRule(35,
+				Violation{Path: "server.go", Message: "became a hotspot with risk score 120.00 (Critical Risk)"})},
+		},
+		{
+			name:    "rules are reported in order",
+			data:    Data{Files: complexity.FilesStat{server}, Current: snap(low), Baseline: snap(low)},
+			opts:    Options{MaxFunctionComplexity: 30, ForbiddenLevels: []string{"Critical Risk"}, Baseline: "baseline.json", HotspotThreshold: 35},
+			results: []Result{complexityRule(30), levelsRule(), hotspotsRule(35)},
+		},
+		{
+			name: "baseline is not loaded",
+			data: Data{Current: snap(low)},
+			opts: Options{Baseline: "baseline.json"},
+			err:  "baseline snapshot is not loaded",
+		},
+		{
+			name: "no rules",
+			data: Data{Files: complexity.FilesStat{server}},
+			opts: Options{},
+			err:  "no rules are enabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Run(tt.data, tt.opts)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.results, results)
+		})
+	}
+}
+
+func TestFailed(t *testing.T) {
+	violation := Violation{Path: "server.go", Function: "Server::Handle", Line: 12, Message: "complexity 25 is above 20"}
+	assert.Equal(t, "server.go:12 Server::Handle: complexity 25 is above 20", violation.String())
+	assert.Equal(t, "util.go: risk score 40.00 is in Low Risk level", Violation{Path: "util.go", Message: "risk score 40.00 is in Low Risk level"}.String())
+
+	results := []Result{
+		{Rule: RuleMaxFunctionComplexity, Violations: []Violation{violation}},
+		{Rule: RuleForbiddenRiskLevels},
+		{Rule: RuleNoNewHotspots, Violations: []Violation{violation}},
+	}
+	assert.Equal(t, 2, Failed(results))
+	assert.Equal(t, 0, Failed(nil))
+}
+
+func TestReports(t *testing.T) {
+	results := []Result{
+		{Rule: RuleMaxFunctionComplexity, Description: "No function has complexity above 20"},
+		{
+			Rule:        RuleForbiddenRiskLevels,
+			Description: "No file has risk level [Critical Risk]",
+			Violations:  []Violation{{Path: "a<b>.go", Message: "risk score 50.00 is in Critical Risk level"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintSummary(results, &buf)
+	assert.Equal(t, "PASS max-function-complexity: No function has complexity above 20\n"+
+		"FAIL forbidden-risk-levels: No file has risk level [Critical Risk]\n"+
+		"    a<b>.go: risk score 50.00 is in Critical Risk level\n"+
+		"\n1 of 2 rules failed\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteJUnit(results, &buf))
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="ccv" tests="2" failures="1">
+  <testsuite name="ccv check" tests="2" failures="1">
+    <testcase name="max-function-complexity" classname="ccv.check"></testcase>
+    <testcase name="forbidden-risk-levels" classname="ccv.check">
+      <failure message="No file has risk level [Critical Risk]: 1 violations" type="forbidden-risk-levels">a&lt;b&gt;.go: risk score 50.00 is in Critical Risk level</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	assert.Equal(t, expected, buf.String())
+}
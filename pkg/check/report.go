@@ -0,0 +1,101 @@
+package check
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PrintSummary writes plain-text report of rules
+func PrintSummary(results []Result, out io.Writer) {
+	for _, result := range results {
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+		}
+
+		fmt.Fprintf(out, "%s %s: %s\n", status, result.Rule, result.Description)
+		for _, violation := range result.Violations {
+			fmt.Fprintf(out, "    %s\n", violation)
+		}
+	}
+
+	fmt.Fprintf(out, "\n%d of %d rules failed\n", Failed(results), len(results))
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes results as JUnit XML report, every rule is a test case
+func WriteJUnit(results []Result, out io.Writer) error {
+	suite := junitTestSuite{
+		Name:     "ccv check",
+		Tests:    len(results),
+		Failures: Failed(results),
+		Cases:    make([]junitTestCase, 0, len(results)),
+	}
+
+	for _, result := range results {
+		testCase := junitTestCase{Name: result.Rule, ClassName: "ccv.check"}
+
+		if !result.Passed() {
+			lines := make([]string, 0, len(result.Violations))
+			for _, violation := range result.Violations {
+				lines = append(lines, violation.String())
+			}
+
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s: %d violations", result.Description, len(result.Violations)),
+				Type:    result.Rule,
+				Text:    strings.Join(lines, "\n"),
+			}
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	report := junitTestSuites{
+		Name:     "ccv",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(out, "\n")
+	return err
+}
@@ -0,0 +1,303 @@
+package complexity
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Name of clang-tidy check reporting cognitive complexity of functions
+const cognitiveComplexityCheck = "readability-function-cognitive-complexity"
+
+// Matches diagnostic line of clang-tidy text output:
+// src/a.cpp:12:6: warning: function 'ns::foo' has cognitive complexity of 27 (threshold 25) [readability-function-cognitive-complexity]
+var clangTidyLineRe = regexp.MustCompile(`^(.+?):(\d+):(\d+): (?:warning|error): (function '.+' has cognitive complexity of \d+.*?) \[` +
+	regexp.QuoteMeta(cognitiveComplexityCheck) + `(?:,[^\]]*)?\]$`)
+
+// Matches diagnostic message shared by text and yaml outputs
+var clangTidyMessageRe = regexp.MustCompile(`^function '(.+)' has cognitive complexity of (\d+)`)
+
+type clangTidyFixes struct {
+	Diagnostics []struct {
+		DiagnosticName    string `yaml:"DiagnosticName"`
+		DiagnosticMessage struct {
+			Message    string `yaml:"Message"`
+			FilePath   string `yaml:"FilePath"`
+			FileOffset int    `yaml:"FileOffset"`
+		} `yaml:"DiagnosticMessage"`
+	} `yaml:"Diagnostics"`
+}
+
+// ImportClangTidy reads cognitive complexity from clang-tidy report.
+// Report is either text output of clang-tidy or a file created with --export-fixes.
+// Clang-tidy reports only functions above the check threshold,
+// so run it with readability-function-cognitive-complexity.Threshold=0 to get all functions.
+// Diagnostics point to start of function only, its length is found by matching braces in source file.
+// Length of function which source file can not be read is zero, such functions are treated as one line long.
+func ImportClangTidy(path string) (FilesStat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadClangTidy(f)
+}
+
+// ReadClangTidy detects format of clang-tidy report and parses it
+func ReadClangTidy(r io.Reader) (FilesStat, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if isClangTidyYAML(data) {
+		return ParseClangTidyYAML(bytes.NewReader(data))
+	}
+
+	return ParseClangTidyText(bytes.NewReader(data))
+}
+
+func isClangTidyYAML(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "Diagnostics:") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseClangTidyText parses diagnostics printed by clang-tidy, notes and other checks are skipped
+func ParseClangTidyText(r io.Reader) (FilesStat, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	collector := newClangTidyCollector()
+	for _, line := range strings.Split(string(data), "\n") {
+		matches := clangTidyLineRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if matches == nil {
+			continue
+		}
+
+		lineNum, _ := strconv.ParseUint(matches[2], 10, 32)
+		column, _ := strconv.ParseUint(matches[3], 10, 32)
+		content := collector.source(matches[1])
+		if err := collector.add(matches[1], uint(lineNum), functionLength(content, lineToOffset(content, uint(lineNum), uint(column))), matches[4]); err != nil {
+			return nil, err
+		}
+	}
+
+	return collector.result(), nil
+}
+
+// ParseClangTidyYAML parses file created with clang-tidy --export-fixes.
+// Diagnostics store byte offsets, so source files are read to convert them to line numbers.
+// If source file can not be read line number is left zero.
+func ParseClangTidyYAML(r io.Reader) (FilesStat, error) {
+	var fixes clangTidyFixes
+	if err := yaml.NewDecoder(r).Decode(&fixes); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse clang-tidy yaml: %w", err)
+	}
+
+	collector := newClangTidyCollector()
+	for _, diag := range fixes.Diagnostics {
+		if diag.DiagnosticName != cognitiveComplexityCheck {
+			continue
+		}
+
+		msg := diag.DiagnosticMessage
+		content := collector.source(msg.FilePath)
+		if err := collector.add(msg.FilePath, offsetToLine(content, msg.FileOffset), functionLength(content, msg.FileOffset), msg.Message); err != nil {
+			return nil, err
+		}
+	}
+
+	return collector.result(), nil
+}
+
+// offsetToLine converts byte offset in file content to 1-based line number,
+// returns 0 if content is unavailable or offset is out of its range
+func offsetToLine(content []byte, offset int) uint {
+	if content == nil || offset < 0 || offset > len(content) {
+		return 0
+	}
+
+	return uint(bytes.Count(content[:offset], []byte("\n")) + 1)
+}
+
+// lineToOffset converts 1-based line and column to byte offset in file content, returns -1 if they are out of its range
+func lineToOffset(content []byte, line, column uint) int {
+	if line == 0 || column == 0 {
+		return -1
+	}
+
+	offset := 0
+	for i := uint(1); i < line; i++ {
+		next := bytes.IndexByte(content[offset:], '\n')
+		if next < 0 {
+			return -1
+		}
+		offset += next + 1
+	}
+
+	if offset+int(column)-1 > len(content) {
+		return -1
+	}
+	return offset + int(column) - 1
+}
+
+// functionLength counts lines from function start at offset to closing brace of its body.
+// Comments, string and character literals are skipped, returns 0 if body is not found.
+// Braces before the body, e.g. in default arguments or member initializers, are not counted.
+func functionLength(content []byte, offset int) uint {
+	if content == nil || offset < 0 || offset > len(content) {
+		return 0
+	}
+
+	// Depth of body braces and of brackets before the body
+	depth, nested := 0, 0
+	initializers := false
+	for i := offset; i < len(content); i++ {
+		switch c := content[i]; {
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			end := bytes.IndexByte(content[i:], '\n')
+			if end < 0 {
+				return 0
+			}
+			i += end
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			if end < 0 {
+				return 0
+			}
+			i += end + 3
+		case c == '"' || c == '\'':
+			for i++; i < len(content) && content[i] != c; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+		case depth > 0:
+			switch c {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					return offsetToLine(content, i) - offsetToLine(content, offset) + 1
+				}
+			}
+		case c == '(' || c == '{' && (nested > 0 || initializers && isMemberInitializer(content[offset:i])):
+			nested++
+		case c == ')' || c == '}':
+			nested = max(nested-1, 0)
+		case nested > 0:
+			continue
+		case c == ':' && !isScopeColon(content, i):
+			// Member initializer list of constructor
+			initializers = true
+		case c == ';':
+			// Declaration without body
+			return 0
+		case c == '{':
+			depth++
+		}
+	}
+
+	return 0
+}
+
+// isMemberInitializer checks whether brace after prefix initializes member, e.g. "value{1}" or "Base<T>{}"
+func isMemberInitializer(prefix []byte) bool {
+	prefix = bytes.TrimRight(prefix, " \t\r\n")
+	if len(prefix) == 0 {
+		return false
+	}
+
+	c := prefix[len(prefix)-1]
+	return c == '_' || c == '>' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isScopeColon checks whether colon at i is a part of "::"
+func isScopeColon(content []byte, i int) bool {
+	return i+1 < len(content) && content[i+1] == ':' || i > 0 && content[i-1] == ':'
+}
+
+// clangTidyCollector groups functions by files keeping order of appearance.
+// Headers are reported once per translation unit, so duplicates are dropped.
+type clangTidyCollector struct {
+	files map[string]*FileStat
+	order []string
+	seen  map[string]bool
+	// Content of source files, nil if file can not be read
+	sources map[string][]byte
+}
+
+func newClangTidyCollector() *clangTidyCollector {
+	return &clangTidyCollector{
+		files:   make(map[string]*FileStat),
+		seen:    make(map[string]bool),
+		sources: make(map[string][]byte),
+	}
+}
+
+// source returns content of source file, every file is read once
+func (c *clangTidyCollector) source(path string) []byte {
+	content, exists := c.sources[path]
+	if !exists {
+		content, _ = os.ReadFile(path)
+		c.sources[path] = content
+	}
+
+	return content
+}
+
+func (c *clangTidyCollector) add(path string, line, length uint, message string) error {
+	matches := clangTidyMessageRe.FindStringSubmatch(message)
+	if matches == nil {
+		return fmt.Errorf("invalid cognitive complexity message: %s", message)
+	}
+
+	key := fmt.Sprintf("%s:%d:%s", path, line, matches[1])
+	if c.seen[key] {
+		return nil
+	}
+	c.seen[key] = true
+
+	funcParts := strings.Split(matches[1], "::")
+	value, _ := strconv.ParseUint(matches[2], 10, 32)
+
+	if _, exists := c.files[path]; !exists {
+		c.files[path] = &FileStat{Path: path}
+		c.order = append(c.order, path)
+	}
+
+	c.files[path].Functions = append(c.files[path].Functions, FunctionStat{
+		File:      path,
+		Package:   funcParts[:len(funcParts)-1],
+		Name:      funcParts[len(funcParts)-1],
+		Line:      line,
+		Length:    length,
+		Compexity: uint(value),
+	})
+
+	return nil
+}
+
+func (c *clangTidyCollector) result() FilesStat {
+	result := make(FilesStat, 0, len(c.order))
+	for _, path := range c.order {
+		result = append(result, c.files[path])
+	}
+
+	return result
+}
@@ -0,0 +1,200 @@
+package complexity
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClangTidyText(t *testing.T) {
+	output := `
+3 warnings generated.
+src/a.cpp:12:6: warning: function 'ns::Parser::parse' has cognitive complexity of 27 (threshold 0) [readability-function-cognitive-complexity]
+src/a.cpp:14:5: note: +1, including nesting penalty of 0, nesting level increased to 1
+src/a.cpp:30:6: warning: function 'helper' has cognitive complexity of 3 (threshold 0) [readability-function-cognitive-complexity]
+src/a.cpp:40:1: warning: use auto [modernize-use-auto]
+include/b.h:5:6: error: function 'inlined' has cognitive complexity of 8 (threshold 0) [readability-function-cognitive-complexity,-warnings-as-errors]
+include/b.h:5:6: warning: function 'inlined' has cognitive complexity of 8 (threshold 0) [readability-function-cognitive-complexity]
+`
+
+	got, err := ParseClangTidyText(strings.NewReader(output))
+	require.NoError(t, err)
+
+	assert.Equal(t, FilesStat{
+		{
+			Path: "src/a.cpp",
+			Functions: FunctionsStat{
+				{File: "src/a.cpp", Package: []string{"ns", "Parser"}, Name: "parse", Line: 12, Compexity: 27},
+				{File: "src/a.cpp", Package: []string{}, Name: "helper", Line: 30, Compexity: 3},
+			},
+		},
+		{
+			Path: "include/b.h",
+			Functions: FunctionsStat{
+				{File: "include/b.h", Package: []string{}, Name: "inlined", Line: 5, Compexity: 8},
+			},
+		},
+	}, got)
+
+	// Length is found in source file from line and column of diagnostic
+	source := filepath.Join(t.TempDir(), "c.cpp")
+	require.NoError(t, os.WriteFile(source, []byte("\nint foo(int a) {\n  return a;\n}\n"), 0o644))
+
+	got, err = ParseClangTidyText(strings.NewReader(source + ":2:5: warning: function 'foo' has cognitive complexity of 0 (threshold 0) [readability-function-cognitive-complexity]\n"))
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, uint(2), got[0].Functions[0].Line)
+	assert.Equal(t, uint(3), got[0].Functions[0].Length)
+}
+
+func TestParseClangTidyYAML(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.cpp")
+	require.NoError(t, os.WriteFile(source, []byte("#include <a.h>\n\nint foo(int a) {\n  return a;\n}\n"), 0o644))
+
+	fixes := fmt.Sprintf(`---
+MainSourceFile:  '%[1]s'
+Diagnostics:
+  - DiagnosticName:  readability-function-cognitive-complexity
+    DiagnosticMessage:
+      Message:         'function ''app::foo'' has cognitive complexity of 4 (threshold 0)'
+      FilePath:        '%[1]s'
+      FileOffset:      20
+      Replacements:    []
+    Level:           Warning
+  - DiagnosticName:  modernize-use-auto
+    DiagnosticMessage:
+      Message:         'use auto'
+      FilePath:        '%[1]s'
+      FileOffset:      1
+      Replacements:    []
+  - DiagnosticName:  readability-function-cognitive-complexity
+    DiagnosticMessage:
+      Message:         'function ''negative'' has cognitive complexity of 1 (threshold 0)'
+      FilePath:        '%[1]s'
+      FileOffset:      -5
+      Replacements:    []
+  - DiagnosticName:  readability-function-cognitive-complexity
+    DiagnosticMessage:
+      Message:         'function ''beyond'' has cognitive complexity of 1 (threshold 0)'
+      FilePath:        '%[1]s'
+      FileOffset:      1000
+      Replacements:    []
+  - DiagnosticName:  readability-function-cognitive-complexity
+    DiagnosticMessage:
+      Message:         'function ''gone'' has cognitive complexity of 2 (threshold 0)'
+      FilePath:        'missing.cpp'
+      FileOffset:      10
+      Replacements:    []
+...
+`, source)
+
+	got, err := ReadClangTidy(strings.NewReader(fixes))
+	require.NoError(t, err)
+
+	assert.Equal(t, FilesStat{
+		{
+			Path: source,
+			Functions: FunctionsStat{
+				{File: source, Package: []string{"app"}, Name: "foo", Line: 3, Length: 3, Compexity: 4},
+				{File: source, Package: []string{}, Name: "negative", Line: 0, Compexity: 1},
+				{File: source, Package: []string{}, Name: "beyond", Line: 0, Compexity: 1},
+			},
+		},
+		{
+			Path: "missing.cpp",
+			Functions: FunctionsStat{
+				{File: "missing.cpp", Package: []string{}, Name: "gone", Line: 0, Compexity: 2},
+			},
+		},
+	}, got)
+}
+
+func TestFunctionLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		length  uint
+	}{
+		{
+			name: "braces in comments and literals",
+			content: `int parse(const char *s) {
+  // closing } in comment
+  if (*s == '}') {
+    return puts("}\"{");
+  }
+  /* { */
+  return 0;
+}
+`,
+			length: 8,
+		},
+		{
+			name: "brace-initialized default argument",
+			content: `void f(std::vector<int> v = {},
+       std::map<int, int> m = {{1, 2}}) {
+  use(v, m);
+}
+`,
+			length: 4,
+		},
+		{
+			name: "lambda in default argument",
+			content: `void run(std::function<int()> fn = [] { return 0; }) {
+  fn();
+}
+`,
+			length: 3,
+		},
+		{
+			name: "brace member initializers",
+			content: `Server::Server(int port)
+    : port_{port},
+      handlers_(std::vector<Handler>{}),
+      Base<int>{} {
+  start();
+}
+`,
+			length: 6,
+		},
+		{
+			name: "qualified return type",
+			content: `std::string Server::name() const {
+  return "server";
+}
+`,
+			length: 3,
+		},
+		{
+			name:    "declaration with default argument",
+			content: "int declared(std::vector<int> v = {});\nint other() {\n}\n",
+			length:  0,
+		},
+		{
+			name:    "unterminated body",
+			content: "int broken() {\n  return 0;\n",
+			length:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.length, functionLength([]byte(tt.content), 0))
+		})
+	}
+
+	content := []byte("int declared(int a);\n")
+	assert.Equal(t, uint(0), functionLength(content, lineToOffset(content, 20, 1)))
+	assert.Equal(t, uint(0), functionLength(content, -1))
+	assert.Equal(t, uint(0), functionLength(nil, 0))
+}
+
+func TestParseClangTidyYAMLInvalid(t *testing.T) {
+	_, err := ParseClangTidyYAML(strings.NewReader("Diagnostics: [unclosed"))
+	assert.Error(t, err)
+}
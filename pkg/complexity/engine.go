@@ -0,0 +1,70 @@
+package complexity
+
+import (
+	"fmt"
+	"slices"
+
+	"golang.org/x/exp/maps"
+)
+
+// Engine is a name under which an Analyzer is registered
+type Engine = string
+
+var (
+	Lizard    Engine = "lizard"
+	LizardXML Engine = "lizard-xml"
+	Golang    Engine = "go"
+	ClangTidy Engine = "clang-tidy"
+)
+
+// Analyzer computes complexity of functions found at path.
+// Depending on analyzer path is either a directory to analyze or a report file to import.
+type Analyzer interface {
+	Analyze(path string) (FilesStat, error)
+}
+
+// AnalyzerFunc allows to use ordinary functions as analyzers
+type AnalyzerFunc func(path string) (FilesStat, error)
+
+func (f AnalyzerFunc) Analyze(path string) (FilesStat, error) {
+	return f(path)
+}
+
+var analyzers = make(map[Engine]Analyzer)
+
+// RegisterAnalyzer makes analyzer available under engine name, registering the same name twice panics
+func RegisterAnalyzer(engine Engine, analyzer Analyzer) {
+	if _, exists := analyzers[engine]; exists {
+		panic(fmt.Sprintf("complexity engine %q is already registered", engine))
+	}
+
+	analyzers[engine] = analyzer
+}
+
+func GetAnalyzer(engine Engine) (Analyzer, error) {
+	analyzer, exists := analyzers[engine]
+	if !exists {
+		return nil, fmt.Errorf("unknown complexity engine %q, use one of the following: %v", engine, Engines())
+	}
+
+	return analyzer, nil
+}
+
+// Engines returns sorted names of all registered analyzers
+func Engines() []Engine {
+	engines := maps.Keys(analyzers)
+	slices.Sort(engines)
+
+	return engines
+}
+
+func init() {
+	RegisterAnalyzer(Lizard, AnalyzerFunc(func(path string) (FilesStat, error) {
+		return RunLizardCmd(path, ComplexityOpts)
+	}))
+	RegisterAnalyzer(LizardXML, AnalyzerFunc(ImportLizardXML))
+	RegisterAnalyzer(Golang, AnalyzerFunc(func(path string) (FilesStat, error) {
+		return RunGoAnalyzer(path, ComplexityOpts)
+	}))
+	RegisterAnalyzer(ClangTidy, AnalyzerFunc(ImportClangTidy))
+}
@@ -0,0 +1,66 @@
+package complexity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngines(t *testing.T) {
+	assert.Equal(t, []Engine{ClangTidy, Golang, Lizard, LizardXML}, Engines())
+}
+
+func TestGetAnalyzer(t *testing.T) {
+	analyzer, err := GetAnalyzer(Golang)
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+
+	files, err := analyzer.Analyze(root)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	_, err = GetAnalyzer("unknown")
+	assert.EqualError(t, err, `unknown complexity engine "unknown", use one of the following: [clang-tidy go lizard lizard-xml]`)
+}
+
+func TestRegisterAnalyzerTwice(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterAnalyzer(Lizard, AnalyzerFunc(func(string) (FilesStat, error) { return nil, nil }))
+	})
+}
+
+func TestImportLizardXML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lizard.xml")
+	require.NoError(t, os.WriteFile(path, []byte(`<?xml version="1.0" ?>
+<cppncss>
+    <measure type="Function">
+        <item name="pkg::some_func(...) at src/file1.cpp:1">
+            <value>1</value>
+            <value>10</value>
+            <value>7</value>
+        </item>
+    </measure>
+    <measure type="File">
+        <item name="src/file1.cpp">
+            <value>1</value>
+            <value>2</value>
+            <value>3</value>
+            <value>4</value>
+        </item>
+    </measure>
+</cppncss>`), 0o644))
+
+	files, err := ImportLizardXML(path)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "src/file1.cpp", files[0].Path)
+	assert.Equal(t, uint(7), files[0].Functions[0].Compexity)
+
+	_, err = ImportLizardXML(filepath.Join(t.TempDir(), "missing.xml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package complexity
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+)
+
+// Directories that never contain project sources
+var skipGoDirs = map[string]bool{
+	".git":     true,
+	"vendor":   true,
+	"testdata": true,
+}
+
+// RunGoAnalyzer walks all *.go files under root and computes cyclomatic complexity of every function.
+// Paths in result are prefixed with root the same way lizard reports them.
+// Files that can not be parsed are skipped like lizard skips them, skipped files are reported to opts.Warnings.
+func RunGoAnalyzer(root string, opts ComplexityOptions) (FilesStat, error) {
+	fset := token.NewFileSet()
+	result := make(FilesStat, 0)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != root && skipGoDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		file, err := AnalyzeGoFile(fset, path, nil)
+		if err != nil {
+			if opts.Warnings != nil {
+				fmt.Fprintf(opts.Warnings, "Warning: skipping %s: %v\n", path, err)
+			}
+			return nil
+		}
+
+		result = append(result, file)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze go files: %w", err)
+	}
+
+	return result, nil
+}
+
+// AnalyzeGoFile parses single go file and computes complexity of its functions.
+// If src is nil file is read from path, otherwise src is used as in parser.ParseFile.
+func AnalyzeGoFile(fset *token.FileSet, path string, src any) (*FileStat, error) {
+	f, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &FileStat{
+		Path:      path,
+		Functions: make(FunctionsStat, 0),
+	}
+
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		start := fset.Position(fn.Pos()).Line
+		end := fset.Position(fn.End()).Line
+
+		file.Functions = append(file.Functions, FunctionStat{
+			File:      path,
+			Package:   receiverType(fn),
+			Name:      fn.Name.Name,
+			Line:      uint(start),
+			Length:    uint(end - start + 1),
+			Compexity: cyclomaticComplexity(fn),
+		})
+	}
+
+	return file, nil
+}
+
+// receiverType returns name of the method receiver type or empty slice for plain functions
+func receiverType(fn *ast.FuncDecl) []string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return []string{}
+	}
+
+	expr := fn.Recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.IndexExpr: // Generic receiver with one type parameter
+			expr = t.X
+		case *ast.IndexListExpr: // Generic receiver with several type parameters
+			expr = t.X
+		case *ast.Ident:
+			return []string{t.Name}
+		default:
+			return []string{}
+		}
+	}
+}
+
+// cyclomaticComplexity counts decision points in function body: 1 + branches + boolean operators.
+// Function literals are counted as a part of enclosing function.
+func cyclomaticComplexity(fn *ast.FuncDecl) uint {
+	var complexity uint = 1
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil { // default branch is not a decision
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+
+	return complexity
+}
@@ -0,0 +1,100 @@
+package complexity
+
+import (
+	"bytes"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goSource = `package main
+
+type Server struct{}
+
+func (s *Server) Handle(code int, ok bool) string {
+	if code > 0 && ok {
+		return "ok"
+	}
+
+	switch code {
+	case 1, 2:
+		return "low"
+	case 3:
+		return "mid"
+	default:
+		return "unknown"
+	}
+}
+
+func (List[T]) Len() int { return 0 }
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		if v > 0 || v < -10 {
+			total += v
+		}
+	}
+	return total
+}
+
+func wait(a, b chan int) {
+	select {
+	case <-a:
+	case <-b:
+	default:
+	}
+}
+
+func noBody()
+`
+
+func TestAnalyzeGoFile(t *testing.T) {
+	file, err := AnalyzeGoFile(token.NewFileSet(), "src/main.go", goSource)
+	require.NoError(t, err)
+
+	assert.Equal(t, "src/main.go", file.Path)
+	assert.Equal(t, FunctionsStat{
+		{File: "src/main.go", Package: []string{"Server"}, Name: "Handle", Line: 5, Length: 14, Compexity: 5},
+		{File: "src/main.go", Package: []string{"List"}, Name: "Len", Line: 20, Length: 1, Compexity: 1},
+		{File: "src/main.go", Package: []string{}, Name: "sum", Line: 22, Length: 9, Compexity: 4},
+		{File: "src/main.go", Package: []string{}, Name: "wait", Line: 32, Length: 7, Compexity: 3},
+	}, file.Functions)
+}
+
+func TestAnalyzeGoFileInvalid(t *testing.T) {
+	_, err := AnalyzeGoFile(token.NewFileSet(), "bad.go", "package main\nfunc {")
+	assert.Error(t, err)
+}
+
+func TestRunGoAnalyzer(t *testing.T) {
+	root := t.TempDir()
+
+	write := func(path, content string) {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+
+	write("main.go", goSource)
+	write("pkg/util.go", "package pkg\n\nfunc Util() {}\n")
+	write("vendor/dep/dep.go", "package dep\n\nfunc Dep() {}\n")
+	write("README.md", "# readme\n")
+	write("pkg/broken.go", "package pkg\n\nfunc {\n")
+
+	var warnings bytes.Buffer
+	results, err := RunGoAnalyzer(root, ComplexityOptions{Warnings: &warnings})
+	require.NoError(t, err)
+	assert.Contains(t, warnings.String(), "skipping "+filepath.Join(root, "pkg/broken.go"))
+
+	paths := make([]string, 0, len(results))
+	for _, file := range results {
+		paths = append(paths, file.Path)
+	}
+
+	assert.ElementsMatch(t, []string{filepath.Join(root, "main.go"), filepath.Join(root, "pkg/util.go")}, paths)
+}
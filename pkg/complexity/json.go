@@ -6,14 +6,36 @@ import (
 )
 
 type churnJSON struct {
+	Metadata struct {
+		Filters struct {
+			HalfLife float64 `json:"half_life"`
+		} `json:"filters"`
+	} `json:"metadata"`
 	Files []*ChurnChunk `json:"files"`
 }
 
+// ChurnReport is churn JSON written by churn command
+type ChurnReport struct {
+	Files []*ChurnChunk
+	// Half-life of weighted churn, zero if weighted churn is not computed
+	HalfLife float64
+}
+
 func ReadChurn(r io.Reader) ([]*ChurnChunk, error) {
+	report, err := ReadChurnReport(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return report.Files, nil
+}
+
+// ReadChurnReport reads files of churn JSON together with options their churn is computed with
+func ReadChurnReport(r io.Reader) (*ChurnReport, error) {
 	var data churnJSON
 	if err := json.NewDecoder(r).Decode(&data); err != nil {
 		return nil, err
 	}
 
-	return data.Files, nil
+	return &ChurnReport{Files: data.Files, HalfLife: data.Metadata.Filters.HalfLife}, nil
 }
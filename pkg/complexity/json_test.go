@@ -48,3 +48,15 @@ func TestReadChurn(t *testing.T) {
 		Commits: 5,
 	})
 }
+
+func TestReadChurnReport(t *testing.T) {
+	jsonData := `{
+        "metadata": {"total_files": 1, "filters": {"half_life": 30}},
+        "files": [{"path": "main.go", "changes": 10, "commits": 2, "weighted_changes": 4.25}]
+    }`
+
+	report, err := ReadChurnReport(strings.NewReader(jsonData))
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, report.HalfLife)
+	assert.Equal(t, []*ChurnChunk{{File: "main.go", Churn: 10, Commits: 2, WeightedChurn: 4.25}}, report.Files)
+}
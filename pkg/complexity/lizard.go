@@ -5,6 +5,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"regexp"
 	"slices"
@@ -12,15 +13,11 @@ import (
 	"strings"
 )
 
-type Engine = string
-
-var (
-	Lizard Engine = "lizard"
-)
-
 type ComplexityOptions struct {
 	Extensions string
 	Threads    int
+	// Writer of warnings about skipped files, nothing is reported if nil
+	Warnings io.Writer
 }
 
 var ComplexityOpts = ComplexityOptions{
@@ -112,6 +109,22 @@ func parseItem(item lizardItem) (FunctionStat, error) {
 	}, nil
 }
 
+// ImportLizardXML reads complexity from lizard report created with -X option
+func ImportLizardXML(path string) (FilesStat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lizard, err := ReadLizardXML(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lizard xml: %w", err)
+	}
+
+	return ParseLizard(lizard)
+}
+
 func ReadLizardXML(r io.Reader) (*lizardXML, error) {
 	var lizard lizardXML
 	if err := xml.NewDecoder(r).Decode(&lizard); err != nil {
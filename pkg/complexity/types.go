@@ -3,6 +3,9 @@ package complexity
 type FileStat struct {
 	Path      string
 	Functions FunctionsStat
+	// Share of covered lines in range [0, 1], valid only if HasCoverage is set
+	Coverage    float64
+	HasCoverage bool
 }
 
 type FilesStat = []*FileStat
@@ -14,14 +17,38 @@ type FunctionStat struct {
 	Line      uint
 	Length    uint
 	Compexity uint
+	// Share of covered lines in range [0, 1], valid only if HasCoverage is set
+	Coverage    float64
+	HasCoverage bool
 }
 
 type FunctionsStat = []FunctionStat
 
 type ChurnChunk struct {
-	File    string `json:"path"`
-	Churn   uint   `json:"changes"`
-	Added   uint   `json:"additions"`
-	Removed uint   `json:"deletions"`
-	Commits uint   `json:"commits"`
+	File      string     `json:"path"`
+	Churn     uint       `json:"changes"`
+	Added     uint       `json:"additions"`
+	Removed   uint       `json:"deletions"`
+	Commits   uint       `json:"commits"`
+	Ownership *Ownership `json:"ownership,omitempty"`
+	// Changes weighted by age of commits, computed only if half-life of churn is set
+	WeightedChurn float64 `json:"weighted_changes,omitempty"`
+}
+
+// Ownership describes how changes of a file are distributed between authors
+type Ownership struct {
+	Authors         uint    `json:"authors"`
+	MainAuthor      string  `json:"main_author"`
+	MainAuthorShare float64 `json:"main_author_share"`
+	// Minimal number of authors that made at least half of the changes
+	BusFactor uint `json:"bus_factor"`
+}
+
+// FunctionChurn is a churn of lines that belong to a function
+type FunctionChurn struct {
+	ChurnChunk
+	Package []string `json:"package"`
+	Name    string   `json:"function"`
+	Line    uint     `json:"line"`
+	Length  uint     `json:"length"`
 }
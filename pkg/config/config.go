@@ -0,0 +1,248 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/vbvictor/ccv/pkg/plot"
+	"gopkg.in/yaml.v3"
+)
+
+// Name of project configuration file searched in repository root
+const FileName = ".ccv.yaml"
+
+// Key of profiles section, all other keys are names of command line flags
+const profilesKey = "profiles"
+
+// Key of risk levels, either path to levels file or inline list of levels
+const riskLevelsKey = "risk-levels"
+
+// Options which values are file paths, relative paths are relative to directory of config file
+var pathOptions = []string{"baseline", "cache-dir", "coverage", "from-log", "ignore-revs-file", "junit", "output", "repo-root", riskLevelsKey}
+
+// Config holds options of project, keys are names of command line flags:
+//
+//	exclude: vendor/
+//	engine: go
+//	risk-levels:
+//	  - {name: Fine, color: "#47d147", min: 0, max: 100}
+//	  - {name: Hotspot, color: "#ff4d4d", min: 100}
+//	profiles:
+//	  ci:
+//	    output-format: csv
+type Config struct {
+	Path     string
+	Options  map[string]any
+	Profiles map[string]map[string]any
+}
+
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Path = path
+
+	return cfg, nil
+}
+
+func Parse(r io.Reader) (*Config, error) {
+	options := make(map[string]any)
+	if err := yaml.NewDecoder(r).Decode(&options); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	cfg := &Config{
+		Options:  options,
+		Profiles: make(map[string]map[string]any),
+	}
+
+	if profiles, exists := options[profilesKey]; exists {
+		delete(options, profilesKey)
+
+		profilesMap, ok := profiles.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a mapping of profile names to options", profilesKey)
+		}
+
+		for name, profile := range profilesMap {
+			profileOptions, ok := profile.(map[string]any)
+			if !ok && profile != nil {
+				return nil, fmt.Errorf("profile %s must be a mapping of options", name)
+			}
+			cfg.Profiles[name] = profileOptions
+		}
+	}
+
+	return cfg, nil
+}
+
+// Find searches config file in start directory and its parents up to repository root.
+// Returns empty path if there is no config file.
+func Find(start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Resolve merges options of profile over common options
+func (c *Config) Resolve(profile string) (map[string]any, error) {
+	result := make(map[string]any, len(c.Options))
+	for key, value := range c.Options {
+		result[key] = value
+	}
+
+	if profile != "" {
+		profileOptions, exists := c.Profiles[profile]
+		if !exists {
+			names := make([]string, 0, len(c.Profiles))
+			for name := range c.Profiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			return nil, fmt.Errorf("unknown profile %q, use one of the following: %v", profile, names)
+		}
+
+		for key, value := range profileOptions {
+			result[key] = value
+		}
+	}
+
+	c.resolvePaths(result)
+
+	return result, nil
+}
+
+// resolvePaths makes relative paths of options relative to directory of config file instead of working directory,
+// "-" stands for standard input and is left as is
+func (c *Config) resolvePaths(options map[string]any) {
+	if c.Path == "" {
+		return
+	}
+
+	dir := filepath.Dir(c.Path)
+	for _, key := range pathOptions {
+		path, ok := options[key].(string)
+		if !ok || path == "" || path == "-" || filepath.IsAbs(path) {
+			continue
+		}
+		options[key] = filepath.Join(dir, path)
+	}
+}
+
+// Apply sets flags to values of options, flags set in command line are left unchanged.
+// Options that are not flags of this command are skipped, known reports whether option is
+// a flag of any command, so misspelled options are reported.
+func Apply(flags *pflag.FlagSet, options map[string]any, known func(name string) bool) error {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !known(key) {
+			return fmt.Errorf("unknown option %q", key)
+		}
+
+		flag := flags.Lookup(key)
+		if flag == nil || flag.Changed {
+			continue
+		}
+
+		value := options[key]
+
+		if key == riskLevelsKey {
+			if levels, ok := value.([]any); ok {
+				if err := applyRiskLevels(levels); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		str, err := formatValue(value)
+		if err != nil {
+			return fmt.Errorf("option %q: %w", key, err)
+		}
+
+		if err := flag.Value.Set(str); err != nil {
+			return fmt.Errorf("option %q: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// applyRiskLevels replaces default risk levels with levels listed in config
+func applyRiskLevels(levels []any) error {
+	data, err := yaml.Marshal(levels)
+	if err != nil {
+		return err
+	}
+
+	riskLevels, err := plot.ReadRiskLevels(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("option %q: %w", riskLevelsKey, err)
+	}
+	plot.RiskLevels = riskLevels
+
+	return nil
+}
+
+// formatValue converts value of option to flag syntax, lists become comma-separated values
+func formatValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case time.Time:
+		return v.Format(time.DateOnly), nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, err := formatValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any:
+		return "", fmt.Errorf("mapping is not a valid value")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbvictor/ccv/pkg/plot"
+)
+
+const testConfig = `
+exclude: vendor/
+ext: [go, cpp]
+top: 20
+since: 2024-01-31
+profiles:
+  ci:
+    top: 5
+    format: json
+  weekly-report:
+`
+
+func TestResolve(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(testConfig))
+	require.NoError(t, err)
+
+	options, err := cfg.Resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, 20, options["top"])
+	assert.NotContains(t, options, "profiles")
+
+	options, err = cfg.Resolve("ci")
+	require.NoError(t, err)
+	assert.Equal(t, 5, options["top"])
+	assert.Equal(t, "json", options["format"])
+	assert.Equal(t, "vendor/", options["exclude"])
+
+	_, err = cfg.Resolve("weekly-report")
+	assert.NoError(t, err)
+
+	_, err = cfg.Resolve("nightly")
+	assert.ErrorContains(t, err, "[ci weekly-report]")
+}
+
+func TestResolvePaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`
+coverage: build/coverage.out
+from-log: "-"
+exclude: vendor/
+profiles:
+  ci:
+    risk-levels: ci/levels.yaml
+    cache-dir: /var/cache/ccv
+`), 0o644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	options, err := cfg.Resolve("ci")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "build", "coverage.out"), options["coverage"])
+	assert.Equal(t, filepath.Join(dir, "ci", "levels.yaml"), options["risk-levels"])
+	assert.Equal(t, "/var/cache/ccv", options["cache-dir"])
+	assert.Equal(t, "-", options["from-log"])
+	assert.Equal(t, "vendor/", options["exclude"])
+}
+
+func TestApply(t *testing.T) {
+	var (
+		exclude, ext, format, since string
+		top                         int
+	)
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&exclude, "exclude", "", "")
+	flags.StringVar(&ext, "ext", "", "")
+	flags.StringVar(&since, "since", "", "")
+	flags.IntVar(&top, "top", 10, "")
+	require.NoError(t, flags.Parse([]string{"--top", "3"}))
+
+	cfg, err := Parse(strings.NewReader(testConfig))
+	require.NoError(t, err)
+	options, err := cfg.Resolve("ci")
+	require.NoError(t, err)
+
+	// format is a flag of another command
+	known := func(name string) bool { return name != "typo" }
+	require.NoError(t, Apply(flags, options, known))
+
+	assert.Equal(t, "vendor/", exclude)
+	assert.Equal(t, "go,cpp", ext)
+	assert.Equal(t, "2024-01-31", since)
+	assert.Equal(t, 3, top) // Command line takes precedence
+	assert.Empty(t, format)
+
+	options["typo"] = 1
+	assert.ErrorContains(t, Apply(flags, options, known), `unknown option "typo"`)
+}
+
+func TestApplyRiskLevels(t *testing.T) {
+	defaults := plot.RiskLevels
+	defer func() { plot.RiskLevels = defaults }()
+
+	var levelsFile string
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&levelsFile, "risk-levels", "", "")
+
+	cfg, err := Parse(strings.NewReader(`
+risk-levels:
+  - {name: Fine, color: "#47d147", min: 0, max: 100}
+  - {name: Hotspot, color: "#ff4d4d", min: 100}
+`))
+	require.NoError(t, err)
+
+	require.NoError(t, Apply(flags, cfg.Options, func(string) bool { return true }))
+	require.Len(t, plot.RiskLevels, 2)
+	assert.Equal(t, "Hotspot", plot.RiskLevels[1].Name)
+	assert.Empty(t, levelsFile)
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src", "pkg"), 0755))
+
+	path, err := Find(filepath.Join(root, "src", "pkg"))
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, FileName), []byte("top: 1\n"), 0644))
+
+	path, err = Find(filepath.Join(root, "src", "pkg"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, FileName), path)
+}
@@ -0,0 +1,251 @@
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Format is a format of coverage report
+type Format = string
+
+var (
+	Auto      Format = "auto"
+	GoCover   Format = "go"
+	LCOV      Format = "lcov"
+	Cobertura Format = "cobertura"
+	Formats          = []Format{Auto, GoCover, LCOV, Cobertura}
+)
+
+// FileCoverage holds number of hits of every instrumented line of a file
+type FileCoverage struct {
+	Path  string
+	Lines map[uint]uint
+}
+
+// Ratio returns share of covered lines among instrumented lines of the file
+func (f *FileCoverage) Ratio() float64 {
+	ratio, _ := f.RangeRatio(0, ^uint(0))
+	return ratio
+}
+
+// RangeRatio returns share of covered lines in [start, end] range,
+// false is returned if there are no instrumented lines in the range
+func (f *FileCoverage) RangeRatio(start, end uint) (float64, bool) {
+	var total, covered uint
+	for line, hits := range f.Lines {
+		if line < start || line > end {
+			continue
+		}
+
+		total++
+		if hits > 0 {
+			covered++
+		}
+	}
+
+	if total == 0 {
+		return 0, false
+	}
+
+	return float64(covered) / float64(total), true
+}
+
+func (f *FileCoverage) addHits(line, hits uint) {
+	f.Lines[line] = max(f.Lines[line], hits)
+}
+
+// Report maps paths as written in coverage report to coverage of files
+type Report map[string]*FileCoverage
+
+func (r Report) file(path string) *FileCoverage {
+	if _, exists := r[path]; !exists {
+		r[path] = &FileCoverage{Path: path, Lines: make(map[uint]uint)}
+	}
+	return r[path]
+}
+
+// Find returns coverage of file by exact path or by the longest path that ends with the same directories.
+// Reports often use absolute paths or import paths, while complexity and churn use repository paths.
+func (r Report) Find(path string) (*FileCoverage, bool) {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if file, exists := r[path]; exists {
+		return file, true
+	}
+
+	var found *FileCoverage
+	foundPath := ""
+	for reportPath, file := range r {
+		reportPath = filepath.ToSlash(filepath.Clean(reportPath))
+		if !hasPathSuffix(reportPath, path) && !hasPathSuffix(path, reportPath) {
+			continue
+		}
+
+		// Prefer the longest matching path, ties are resolved alphabetically to keep result stable
+		if found == nil || len(reportPath) > len(foundPath) ||
+			(len(reportPath) == len(foundPath) && reportPath < foundPath) {
+			found, foundPath = file, reportPath
+		}
+	}
+
+	return found, found != nil
+}
+
+// hasPathSuffix reports whether path ends with suffix on path separator boundary
+func hasPathSuffix(path, suffix string) bool {
+	return strings.HasSuffix(path, "/"+strings.TrimPrefix(suffix, "./"))
+}
+
+// ReadReport reads coverage report file of given format, Auto detects format by content
+func ReadReport(path string, format Format) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(bytes.NewReader(data), format)
+}
+
+func Parse(r io.Reader, format Format) (Report, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == Auto {
+		format = detectFormat(data)
+	}
+
+	switch format {
+	case GoCover:
+		return ParseGoCover(bytes.NewReader(data))
+	case LCOV:
+		return ParseLCOV(bytes.NewReader(data))
+	case Cobertura:
+		return ParseCobertura(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unknown coverage format %q, use one of the following: %v", format, Formats)
+	}
+}
+
+func detectFormat(data []byte) Format {
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		return GoCover
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return Cobertura
+	default:
+		return LCOV
+	}
+}
+
+// ParseGoCover parses profile written by go test -coverprofile.
+// Every line of a block gets the block count, overlapping blocks keep the maximum.
+func ParseGoCover(r io.Reader) (Report, error) {
+	report := make(Report)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// name.go:line.column,line.column numberOfStatements count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("invalid go coverage line: %s", line)
+		}
+
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid go coverage line: %s", line)
+		}
+
+		start, end, found := strings.Cut(fields[0], ",")
+		startLine, errStart := strconv.ParseUint(strings.Split(start, ".")[0], 10, 32)
+		endLine, errEnd := strconv.ParseUint(strings.Split(end, ".")[0], 10, 32)
+		count, errCount := strconv.ParseUint(fields[2], 10, 32)
+		if !found || errStart != nil || errEnd != nil || errCount != nil {
+			return nil, fmt.Errorf("invalid go coverage line: %s", line)
+		}
+
+		file := report.file(line[:colon])
+		for l := startLine; l <= endLine; l++ {
+			file.addHits(uint(l), uint(count))
+		}
+	}
+
+	return report, scanner.Err()
+}
+
+// ParseLCOV parses SF and DA records of lcov tracefile
+func ParseLCOV(r io.Reader) (Report, error) {
+	report := make(Report)
+	scanner := bufio.NewScanner(r)
+
+	var file *FileCoverage
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file = report.file(strings.TrimPrefix(line, "SF:"))
+		case strings.HasPrefix(line, "DA:"):
+			// DA:line,hits[,checksum]
+			parts := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if file == nil || len(parts) < 2 {
+				return nil, fmt.Errorf("invalid lcov line: %s", line)
+			}
+
+			lineNum, errLine := strconv.ParseUint(parts[0], 10, 32)
+			hits, errHits := strconv.ParseUint(parts[1], 10, 32)
+			if errLine != nil || errHits != nil {
+				return nil, fmt.Errorf("invalid lcov line: %s", line)
+			}
+
+			file.addHits(uint(lineNum), uint(hits))
+		case line == "end_of_record":
+			file = nil
+		}
+	}
+
+	return report, scanner.Err()
+}
+
+type coberturaXML struct {
+	XMLName xml.Name `xml:"coverage"`
+	Classes []struct {
+		Filename string `xml:"filename,attr"`
+		Lines    []struct {
+			Number uint `xml:"number,attr"`
+			Hits   uint `xml:"hits,attr"`
+		} `xml:"lines>line"`
+	} `xml:"packages>package>classes>class"`
+}
+
+// ParseCobertura parses line hits of classes in cobertura xml report
+func ParseCobertura(r io.Reader) (Report, error) {
+	var cobertura coberturaXML
+	if err := xml.NewDecoder(r).Decode(&cobertura); err != nil {
+		return nil, fmt.Errorf("failed to parse cobertura xml: %w", err)
+	}
+
+	report := make(Report)
+	for _, class := range cobertura.Classes {
+		file := report.file(class.Filename)
+		for _, line := range class.Lines {
+			file.addHits(line.Number, line.Hits)
+		}
+	}
+
+	return report, nil
+}
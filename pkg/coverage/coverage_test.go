@@ -0,0 +1,149 @@
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goProfile = `mode: set
+github.com/org/repo/pkg/a.go:3.14,5.2 1 1
+github.com/org/repo/pkg/a.go:5.2,7.3 2 0
+github.com/org/repo/main.go:10.1,10.20 1 0
+`
+
+const lcovReport = `TN:
+SF:/home/user/repo/src/a.cpp
+DA:1,5
+DA:2,0
+DA:3,1,checksum
+end_of_record
+SF:src/b.cpp
+DA:10,0
+end_of_record
+`
+
+const coberturaReport = `<?xml version="1.0" ?>
+<coverage line-rate="0.5">
+  <sources><source>/home/user/repo</source></sources>
+  <packages>
+    <package name="src">
+      <classes>
+        <class name="a.py" filename="src/a.py">
+          <methods/>
+          <lines>
+            <line number="1" hits="1"/>
+            <line number="2" hits="0"/>
+          </lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>
+`
+
+func TestParseGoCover(t *testing.T) {
+	report, err := Parse(strings.NewReader(goProfile), Auto)
+	require.NoError(t, err)
+
+	require.Len(t, report, 2)
+	assert.Equal(t, map[uint]uint{3: 1, 4: 1, 5: 1, 6: 0, 7: 0}, report["github.com/org/repo/pkg/a.go"].Lines)
+	assert.Equal(t, 0.6, report["github.com/org/repo/pkg/a.go"].Ratio())
+	assert.Equal(t, 0.0, report["github.com/org/repo/main.go"].Ratio())
+
+	_, err = ParseGoCover(strings.NewReader("mode: set\nbroken line\n"))
+	assert.Error(t, err)
+}
+
+func TestParseLCOV(t *testing.T) {
+	report, err := Parse(strings.NewReader(lcovReport), Auto)
+	require.NoError(t, err)
+
+	require.Len(t, report, 2)
+	assert.Equal(t, map[uint]uint{1: 5, 2: 0, 3: 1}, report["/home/user/repo/src/a.cpp"].Lines)
+	assert.Equal(t, map[uint]uint{10: 0}, report["src/b.cpp"].Lines)
+
+	_, err = ParseLCOV(strings.NewReader("DA:1,1\n"))
+	assert.Error(t, err)
+}
+
+func TestParseCobertura(t *testing.T) {
+	report, err := Parse(strings.NewReader(coberturaReport), Auto)
+	require.NoError(t, err)
+
+	require.Len(t, report, 1)
+	assert.Equal(t, map[uint]uint{1: 1, 2: 0}, report["src/a.py"].Lines)
+
+	_, err = ParseCobertura(strings.NewReader("<coverage>"))
+	assert.Error(t, err)
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	_, err := Parse(strings.NewReader(""), "xml")
+	assert.EqualError(t, err, `unknown coverage format "xml", use one of the following: [auto go lcov cobertura]`)
+}
+
+func TestRangeRatio(t *testing.T) {
+	file := &FileCoverage{Lines: map[uint]uint{1: 1, 2: 0, 3: 2, 10: 0}}
+
+	ratio, ok := file.RangeRatio(1, 3)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.67, ratio, 0.01)
+
+	_, ok = file.RangeRatio(4, 9)
+	assert.False(t, ok)
+
+	assert.Equal(t, 0.5, file.Ratio())
+}
+
+func TestFind(t *testing.T) {
+	report, err := ParseGoCover(strings.NewReader(goProfile))
+	require.NoError(t, err)
+
+	lcov, err := ParseLCOV(strings.NewReader(lcovReport))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		report Report
+		path   string
+		want   string
+	}{
+		{name: "import path", report: report, path: "pkg/a.go", want: "github.com/org/repo/pkg/a.go"},
+		{name: "dot prefix", report: report, path: "./main.go", want: "github.com/org/repo/main.go"},
+		{name: "absolute path", report: lcov, path: "src/a.cpp", want: "/home/user/repo/src/a.cpp"},
+		{name: "exact path", report: lcov, path: "src/b.cpp", want: "src/b.cpp"},
+		{name: "report path is suffix", report: lcov, path: "/abs/repo/src/b.cpp", want: "src/b.cpp"},
+		{name: "partial file name", report: report, path: "a.go/x", want: ""},
+		{name: "missing", report: lcov, path: "src/c.cpp", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, found := tt.report.Find(tt.path)
+			if tt.want == "" {
+				assert.False(t, found)
+				return
+			}
+
+			require.True(t, found)
+			assert.Equal(t, tt.want, file.Path)
+		})
+	}
+}
+
+func TestReadReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lcov.info")
+	require.NoError(t, os.WriteFile(path, []byte(lcovReport), 0o644))
+
+	report, err := ReadReport(path, LCOV)
+	require.NoError(t, err)
+	assert.Len(t, report, 2)
+
+	_, err = ReadReport(filepath.Join(t.TempDir(), "missing"), Auto)
+	assert.Error(t, err)
+}
@@ -0,0 +1,289 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Version of cache format, files of other versions are not read
+const cacheVersion = 2
+
+// Commits missing in cache are read from git in batches of this size
+const cacheBatchSize = 1000
+
+// commitCache keeps numstat of commits in JSON lines file, one commit per line.
+// Numstat of commit depends on pathspecs and diff of merges, so each combination of them has its own file.
+// Only positions of cached commits are kept in memory, commits are decoded when they are read.
+type commitCache struct {
+	path  string
+	file  *os.File
+	w     *bufio.Writer
+	index map[string]cacheEntry
+	size  int64
+}
+
+// cacheEntry is position of commit line in cache file
+type cacheEntry struct {
+	offset int64
+	length int64
+}
+
+// cacheDir returns directory of commit cache, ccv directory inside .git by default
+func cacheDir(ctx context.Context, repoPath string, opts ChurnOptions) (string, error) {
+	if opts.CacheDir != "" {
+		return opts.CacheDir, nil
+	}
+
+	gitDir, err := gitOutput(ctx, repoPath, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", fmt.Errorf("error finding git directory: %w", err)
+	}
+
+	return filepath.Join(gitDir, "ccv"), nil
+}
+
+// cacheKey identifies options that change numstat of a commit
+func cacheKey(opts ChurnOptions) string {
+	parts := []string{fmt.Sprint(cacheVersion), filepath.ToSlash(opts.Path)}
+	parts = append(parts, opts.Pathspecs...)
+	parts = append(parts, diffArgs(opts)...)
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// openCommitCache indexes cached commits of key in dir, cache must be closed to write added commits.
+// Cache is read up to the first broken line, e.g. left by interrupted run, and the rest is dropped.
+func openCommitCache(dir, key string) (*commitCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("numstat-v%d-%s.jsonl", cacheVersion, key))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &commitCache{
+		path:  path,
+		file:  f,
+		w:     bufio.NewWriter(f),
+		index: make(map[string]cacheEntry),
+	}
+
+	if err := cache.read(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if info, err := f.Stat(); err == nil && info.Size() != cache.size {
+		if err := f.Truncate(cache.size); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	return cache, nil
+}
+
+// read indexes commits and sets size to size of valid part of cache
+func (c *commitCache) read(r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read commit cache: %w", err)
+		}
+
+		var cached struct {
+			Hash string `json:"hash"`
+		}
+		if err := json.Unmarshal(line, &cached); err != nil || !isCommitHash(cached.Hash) {
+			return nil
+		}
+
+		c.index[cached.Hash] = cacheEntry{offset: c.size, length: int64(len(line))}
+		c.size += int64(len(line))
+	}
+}
+
+// get reads commit from cache, returns nil if commit is not cached
+func (c *commitCache) get(hash string) (*commit, error) {
+	entry, exists := c.index[hash]
+	if !exists {
+		return nil, nil
+	}
+
+	if c.w.Buffered() > 0 {
+		if err := c.w.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	line := make([]byte, entry.length)
+	if _, err := c.file.ReadAt(line, entry.offset); err != nil {
+		return nil, fmt.Errorf("failed to read commit cache: %w", err)
+	}
+
+	var cached commit
+	if err := json.Unmarshal(line, &cached); err != nil {
+		return nil, fmt.Errorf("failed to read commit %s from cache: %w", hash, err)
+	}
+
+	return &cached, nil
+}
+
+// add appends commit to cache file
+func (c *commitCache) add(commit *commit) error {
+	line, err := json.Marshal(commit)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if _, err := c.w.Write(line); err != nil {
+		return err
+	}
+
+	c.index[commit.Hash] = cacheEntry{offset: c.size, length: int64(len(line))}
+	c.size += int64(len(line))
+
+	return nil
+}
+
+// close writes added commits and closes cache file
+func (c *commitCache) close() error {
+	err := c.w.Flush()
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// readCachedCommits streams commits selected by options and reads numstat only of commits missing in cache.
+// Commits are passed to fn in the same order as git log lists them, fresh commits are cached as they are parsed.
+func readCachedCommits(ctx context.Context, repoPath string, opts ChurnOptions, fn func(*commit) error) error {
+	dir, err := cacheDir(ctx, repoPath, opts)
+	if err != nil {
+		return err
+	}
+
+	cache, err := openCommitCache(dir, cacheKey(opts))
+	if err != nil {
+		return fmt.Errorf("error opening commit cache: %w", err)
+	}
+
+	missing := make([]string, 0, cacheBatchSize)
+	readMissing := func() error {
+		if len(missing) == 0 {
+			return nil
+		}
+
+		err := readCommitsByHash(ctx, repoPath, opts, missing, func(c *commit) error {
+			if err := cache.add(c); err != nil {
+				return fmt.Errorf("error writing commit cache: %w", err)
+			}
+			return fn(c)
+		})
+		missing = missing[:0]
+		return err
+	}
+
+	err = listCommits(ctx, repoPath, opts, func(hash string) error {
+		c, err := cache.get(hash)
+		if err != nil {
+			return err
+		}
+
+		if c == nil {
+			missing = append(missing, hash)
+			if len(missing) == cacheBatchSize {
+				return readMissing()
+			}
+			return nil
+		}
+
+		// Commits read before are passed first to keep order of history
+		if err := readMissing(); err != nil {
+			return err
+		}
+		return fn(c)
+	})
+	if err == nil {
+		err = readMissing()
+	}
+
+	if closeErr := cache.close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error writing commit cache: %w", closeErr)
+	}
+
+	return err
+}
+
+// listCommits passes hashes of commits selected by filters of options to fn in order of git log
+func listCommits(ctx context.Context, repoPath string, opts ChurnOptions, fn func(hash string) error) error {
+	if len(opts.Revisions) == 0 {
+		opts.Revisions = []string{"HEAD"}
+	}
+
+	gitCmd, err := pathspecCommand(ctx, repoPath, opts, append([]string{"rev-list"}, logFilterArgs(opts)...)...)
+	if err != nil {
+		return err
+	}
+
+	return streamCommand(ctx, gitCmd, func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if err := fn(scanner.Text()); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	})
+}
+
+// readCommitsByHash reads numstat of given commits without walking their history and passes them to fn in given order
+func readCommitsByHash(ctx context.Context, repoPath string, opts ChurnOptions, hashes []string, fn func(*commit) error) error {
+	args := []string{"log", "--no-walk=unsorted", "--stdin", prettyFormat(), "--numstat", "-z", "-M"}
+	args = append(args, diffArgs(opts)...)
+
+	gitCmd, err := pathspecCommand(ctx, repoPath, ChurnOptions{Path: opts.Path, Pathspecs: opts.Pathspecs}, args...)
+	if err != nil {
+		return err
+	}
+	gitCmd.Stdin = strings.NewReader(strings.Join(hashes, "\n") + "\n")
+
+	read := 0
+	err = streamCommand(ctx, gitCmd, func(r io.Reader) error {
+		return parseNumstat(r, func(c *commit) error {
+			if read >= len(hashes) || c.Hash != hashes[read] {
+				return fmt.Errorf("unexpected commit %s in git log output", c.Hash)
+			}
+			read++
+			return fn(c)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if read < len(hashes) {
+		return fmt.Errorf("commit %s is missing in git log output", hashes[read])
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheKey(t *testing.T) {
+	base := cacheKey(ChurnOptions{})
+
+	// Filters that select commits do not change numstat of commits
+	assert.Equal(t, base, cacheKey(ChurnOptions{CommitCount: 5, NoMerges: true, Revisions: []string{"v1..v2"}, ExcludePath: "vendor/"}))
+
+	assert.NotEqual(t, base, cacheKey(ChurnOptions{FirstParent: true}))
+	assert.NotEqual(t, base, cacheKey(ChurnOptions{Pathspecs: []string{"*.go"}}))
+	assert.NotEqual(t, base, cacheKey(ChurnOptions{Path: "src"}))
+	assert.NotEqual(t, base, cacheKey(ChurnOptions{IgnoreWhitespace: true}))
+}
+
+func TestCommitCache(t *testing.T) {
+	dir := t.TempDir()
+
+	commits := make([]*commit, 0)
+	require.NoError(t, parseNumstat(strings.NewReader(numstatLog), func(c *commit) error {
+		commits = append(commits, c)
+		return nil
+	}))
+
+	cache, err := openCommitCache(dir, "key")
+	require.NoError(t, err)
+	assert.Empty(t, cache.index)
+	for _, c := range commits[:2] {
+		require.NoError(t, cache.add(c))
+	}
+
+	// Commits added in this run are read back before cache is closed
+	got, err := cache.get(commits[1].Hash)
+	require.NoError(t, err)
+	assert.Equal(t, commits[1], got)
+	require.NoError(t, cache.close())
+
+	// Interrupted write leaves broken line that is dropped
+	f, err := os.OpenFile(cache.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"hash":"` + hash3)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	cache, err = openCommitCache(dir, "key")
+	require.NoError(t, err)
+	assert.Len(t, cache.index, 2)
+	for _, c := range commits[2:] {
+		require.NoError(t, cache.add(c))
+	}
+	require.NoError(t, cache.close())
+
+	cache, err = openCommitCache(dir, "key")
+	require.NoError(t, err)
+	defer cache.close()
+
+	require.Len(t, cache.index, 4)
+	for _, c := range commits {
+		got, err := cache.get(c.Hash)
+		require.NoError(t, err)
+		assert.Equal(t, c, got)
+	}
+
+	got, err = cache.get("0000000000000000000000000000000000000000")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestReadChurnCached(t *testing.T) {
+	tmpDir := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+
+	Unbundle(t, "../../test/bundles/churn-test.bundle", tmpDir)
+
+	expected, err := ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: Changes, Top: -1})
+	require.NoError(t, err)
+
+	// Older commits are cached first, so newer commits read from git are passed before cached ones
+	_, err = ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: Changes, Top: -1, CacheDir: cacheDir, Revisions: []string{"HEAD~3"}})
+	require.NoError(t, err)
+
+	got, err := ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: Changes, Top: -1, CacheDir: cacheDir})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, got)
+
+	got, err = ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: Changes, Top: -1, CacheDir: cacheDir, Revisions: []string{"HEAD~3..HEAD"}})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "main.cpp", got[0].File)
+	assert.Equal(t, uint(11), got[0].Churn)
+
+	// Later runs read numstat from cache
+	files, err := filepath.Glob(filepath.Join(cacheDir, "*.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(files[0], []byte(strings.ReplaceAll(string(content), `"path":"main.go","added":7`, `"path":"main.go","added":70`)), 0o644))
+
+	got, err = ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: Changes, Top: 1, CacheDir: cacheDir})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "main.go", got[0].File)
+	assert.Equal(t, uint(70), got[0].Churn)
+}
@@ -0,0 +1,153 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+type CouplingOptions struct {
+	// Minimal number of commits in which both files changed
+	MinSharedCommits uint
+	// Minimal number of commits of each file in pair
+	MinRevisions uint
+	// Commits that modify more files are skipped, 0 disables the limit
+	MaxChangesetSize int
+}
+
+var CouplingOpts = CouplingOptions{
+	MinSharedCommits: 5,
+	MinRevisions:     5,
+	MaxChangesetSize: 30,
+}
+
+// Coupling shows how often two files change together
+type Coupling struct {
+	File             string `json:"file"`
+	Coupled          string `json:"coupled"`
+	SharedCommits    uint   `json:"shared_commits"`
+	Revisions        uint   `json:"revisions"`
+	CoupledRevisions uint   `json:"coupled_revisions"`
+	// Shared commits divided by number of commits that modified any of files
+	Degree float64 `json:"degree"`
+}
+
+func PrintRepoCoupling(ctx context.Context, repoPath string) error {
+	couplings, err := ReadCoupling(ctx, repoPath, ChurnOpts, CouplingOpts)
+	if err != nil {
+		return fmt.Errorf("error getting coupling metrics: %w", err)
+	}
+
+	return printCoupling(couplings, os.Stdout, ChurnOpts, CouplingOpts)
+}
+
+func ReadCoupling(ctx context.Context, repoPath string, opts ChurnOptions, copts CouplingOptions) ([]*Coupling, error) {
+	aggregator := newCouplingAggregator(opts, copts)
+	if _, err := readCommits(ctx, repoPath, opts, aggregator.renames, aggregator.add); err != nil {
+		return nil, err
+	}
+
+	return limitCouplings(aggregator.result(), opts.Top), nil
+}
+
+type filePair struct {
+	first, second string
+}
+
+// couplingAggregator counts commits of every file and every pair of files modified in the same commit
+type couplingAggregator struct {
+	opts      ChurnOptions
+	copts     CouplingOptions
+	renames   renameTracker
+	revisions map[string]uint
+	shared    map[filePair]uint
+}
+
+func newCouplingAggregator(opts ChurnOptions, copts CouplingOptions) *couplingAggregator {
+	return &couplingAggregator{
+		opts:      opts,
+		copts:     copts,
+		renames:   make(renameTracker),
+		revisions: make(map[string]uint),
+		shared:    make(map[filePair]uint),
+	}
+}
+
+func (a *couplingAggregator) add(c *commit) error {
+	modifiedInCommit := make(map[string]bool)
+	for _, change := range c.Files {
+		filepath := a.renames.track(change.OldPath, change.Path)
+		if shouldSkipFile(filepath, a.opts.ExcludePath, a.opts.Extensions) {
+			continue
+		}
+		modifiedInCommit[filepath] = true
+	}
+
+	if a.copts.MaxChangesetSize > 0 && len(modifiedInCommit) > a.copts.MaxChangesetSize {
+		return nil
+	}
+
+	files := make([]string, 0, len(modifiedInCommit))
+	for filepath := range modifiedInCommit {
+		files = append(files, filepath)
+		a.revisions[filepath]++
+	}
+	sort.Strings(files)
+
+	for i := 0; i < len(files); i++ {
+		for j := i + 1; j < len(files); j++ {
+			a.shared[filePair{files[i], files[j]}]++
+		}
+	}
+
+	return nil
+}
+
+func (a *couplingAggregator) result() []*Coupling {
+	result := make([]*Coupling, 0)
+
+	for pair, shared := range a.shared {
+		revisions, coupledRevisions := a.revisions[pair.first], a.revisions[pair.second]
+		if shared < a.copts.MinSharedCommits ||
+			revisions < a.copts.MinRevisions || coupledRevisions < a.copts.MinRevisions {
+			continue
+		}
+
+		degree := float64(shared) / float64(revisions+coupledRevisions-shared)
+
+		result = append(result, &Coupling{
+			File:             pair.first,
+			Coupled:          pair.second,
+			SharedCommits:    shared,
+			Revisions:        revisions,
+			CoupledRevisions: coupledRevisions,
+			Degree:           math.Round(degree*100) / 100,
+		})
+	}
+
+	return result
+}
+
+// limitCouplings sorts couplings by degree and shared commits in descending order
+func limitCouplings(result []*Coupling, limit int) []*Coupling {
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Degree != result[j].Degree {
+			return result[i].Degree > result[j].Degree
+		}
+		if result[i].SharedCommits != result[j].SharedCommits {
+			return result[i].SharedCommits > result[j].SharedCommits
+		}
+		if result[i].File != result[j].File {
+			return result[i].File < result[j].File
+		}
+		return result[i].Coupled < result[j].Coupled
+	})
+
+	if limit >= 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
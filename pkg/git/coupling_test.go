@@ -0,0 +1,106 @@
+package git
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCouplingAggregator(t *testing.T) {
+	commits := []*commit{
+		{Hash: hash1, Files: []fileChange{{Path: "a.go"}, {Path: "b.go"}, {Path: "vendor/x.go"}}},
+		{Hash: hash2, Files: []fileChange{{Path: "a.go"}, {Path: "b.go"}, {Path: "c.go"}}},
+		{Hash: hash3, Files: []fileChange{{Path: "a.go"}, {Path: "old_b.go"}}},
+		{Hash: hash4, Files: []fileChange{{Path: "b.go", OldPath: "old_b.go"}, {Path: "c.go"}, {Path: "d.go"}, {Path: "e.go"}}},
+	}
+
+	// Commits are in order from newest to oldest, so rename of b.go must come first
+	commits[2], commits[3] = commits[3], commits[2]
+
+	aggregator := newCouplingAggregator(ChurnOptions{ExcludePath: "vendor/"}, CouplingOptions{
+		MinSharedCommits: 2,
+		MinRevisions:     2,
+		MaxChangesetSize: 3,
+	})
+	for _, c := range commits {
+		require.NoError(t, aggregator.add(c))
+	}
+
+	result := limitCouplings(aggregator.result(), -1)
+
+	assert.Equal(t, []*Coupling{
+		{File: "a.go", Coupled: "b.go", SharedCommits: 3, Revisions: 3, CoupledRevisions: 3, Degree: 1},
+	}, result)
+}
+
+func TestLimitCouplings(t *testing.T) {
+	couplings := []*Coupling{
+		{File: "a", Coupled: "b", SharedCommits: 2, Degree: 0.5},
+		{File: "a", Coupled: "c", SharedCommits: 5, Degree: 0.5},
+		{File: "b", Coupled: "c", SharedCommits: 1, Degree: 0.9},
+	}
+
+	result := limitCouplings(couplings, 2)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "c", result[0].Coupled)
+	assert.Equal(t, "b", result[0].File)
+	assert.Equal(t, uint(5), result[1].SharedCommits)
+}
+
+func TestPrintCouplingTable(t *testing.T) {
+	var buf bytes.Buffer
+
+	printCouplingTable([]*Coupling{
+		{File: "a.go", Coupled: "b.go", SharedCommits: 3, Revisions: 4, CoupledRevisions: 5, Degree: 0.5},
+	}, &buf, ChurnOptions{Top: 1})
+
+	expected := "\nTop 1 most coupled files (shared commits / commits of either file):\n" +
+		strings.Repeat("-", 100) + "\n" +
+		"DEGREE   SHARED   REVS     REVS     FILES\n" +
+		strings.Repeat("-", 100) + "\n" +
+		"0.50     3        4        5        a.go <-> b.go\n"
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestPrintCouplingJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	printCouplingJSON([]*Coupling{
+		{File: "a.go", Coupled: "b.go", SharedCommits: 3, Revisions: 4, CoupledRevisions: 5, Degree: 0.5},
+	}, &buf, ChurnOptions{SortBy: Changes}, CouplingOptions{MinSharedCommits: 2, MinRevisions: 3, MaxChangesetSize: 10})
+
+	expected := `{
+  "metadata": {
+    "total_files": 2,
+    "sort_by": "changes",
+    "filters": {
+      "path": "",
+      "exclude_pattern": "",
+      "extensions": "",
+      "date_range": {
+        "since": "0001-01-01",
+        "until": "0001-01-01"
+      }
+    },
+    "min_shared_commits": 2,
+    "min_revisions": 3,
+    "max_changeset_size": 10
+  },
+  "couplings": [
+    {
+      "file": "a.go",
+      "coupled": "b.go",
+      "shared_commits": 3,
+      "revisions": 4,
+      "coupled_revisions": 5,
+      "degree": 0.5
+    }
+  ]
+}`
+	assert.JSONEq(t, expected, buf.String())
+}
@@ -0,0 +1,315 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LineRange is a region of lines of file, range without lines is a point after Start
+type LineRange struct {
+	Start uint
+	Lines uint
+}
+
+// Overlaps checks whether range intersects lines [start, end), empty range is treated as the line it follows
+func (r LineRange) Overlaps(start, end uint) bool {
+	if r.Lines == 0 {
+		line := max(r.Start, 1)
+		return line >= start && line < end
+	}
+	return r.Start < end && start < r.Start+r.Lines
+}
+
+// FileDiff is a file changed between two revisions
+type FileDiff struct {
+	Path    string // Empty if file was deleted
+	OldPath string // Empty if file was added
+	Added   uint
+	Removed uint
+	// Changed regions in old and new versions of file
+	OldRanges []LineRange
+	NewRanges []LineRange
+}
+
+func (d FileDiff) Status() string {
+	switch {
+	case d.OldPath == "":
+		return "added"
+	case d.Path == "":
+		return "deleted"
+	case d.OldPath != d.Path:
+		return "renamed"
+	default:
+		return "modified"
+	}
+}
+
+// ParseRange resolves revision range "base..head" or "base...head" to commit hashes.
+// Three dots compare head with merge base of revisions, omitted head means HEAD.
+func ParseRange(ctx context.Context, repoPath, revRange string) (base, head string, err error) {
+	symmetric := strings.Contains(revRange, "...")
+	separator := ".."
+	if symmetric {
+		separator = "..."
+	}
+
+	baseRev, headRev, found := strings.Cut(revRange, separator)
+	if !found || baseRev == "" {
+		return "", "", fmt.Errorf("invalid revision range %q, expected <base>..<head>", revRange)
+	}
+	if headRev == "" {
+		headRev = "HEAD"
+	}
+
+	if head, err = gitOutput(ctx, repoPath, "rev-parse", "--verify", headRev+"^{commit}"); err != nil {
+		return "", "", fmt.Errorf("unknown revision %q: %w", headRev, err)
+	}
+
+	if symmetric {
+		base, err = gitOutput(ctx, repoPath, "merge-base", baseRev, head)
+	} else {
+		base, err = gitOutput(ctx, repoPath, "rev-parse", "--verify", baseRev+"^{commit}")
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("unknown revision %q: %w", baseRev, err)
+	}
+
+	return base, head, nil
+}
+
+// ReadDiff lists files changed between base and head with changed line ranges, files are filtered by opts
+func ReadDiff(ctx context.Context, repoPath, base, head string, opts ChurnOptions) ([]FileDiff, error) {
+	gitCmd := exec.CommandContext(ctx, "git", "-c", "core.quotePath=false", "diff", "--unified=0", "-M", "--no-color", "--no-ext-diff", base, head)
+	gitCmd.Dir = repoPath
+	output, err := gitCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute git command: %v", err)
+	}
+
+	changes, err := parseDiff(bytes.NewReader(output))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]FileDiff, 0, len(changes))
+	for _, change := range changes {
+		path := change.Path
+		if path == "" {
+			path = change.OldPath
+		}
+
+		if !shouldSkipFile(path, opts.ExcludePath, opts.Extensions) {
+			result = append(result, change)
+		}
+	}
+
+	return result, nil
+}
+
+func parseDiff(r io.Reader) ([]FileDiff, error) {
+	result := make([]FileDiff, 0)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	var current *FileDiff
+	finish := func() {
+		if current != nil {
+			result = append(result, *current)
+		}
+		current = nil
+	}
+
+	inHeader := false
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			finish()
+			path := headerPath(line[len("diff --git "):])
+			current = &FileDiff{Path: path, OldPath: path}
+			inHeader = true
+		case current == nil:
+			continue
+		case inHeader && strings.HasPrefix(line, "new file mode"):
+			current.OldPath = ""
+		case inHeader && strings.HasPrefix(line, "deleted file mode"):
+			current.Path = ""
+		case inHeader && strings.HasPrefix(line, "--- "):
+			current.OldPath = diffPath(line[4:], "a/")
+		case inHeader && strings.HasPrefix(line, "+++ "):
+			current.Path = diffPath(line[4:], "b/")
+		case inHeader && strings.HasPrefix(line, "rename from "):
+			current.OldPath = unquotePath(line[len("rename from "):])
+		case inHeader && strings.HasPrefix(line, "rename to "):
+			current.Path = unquotePath(line[len("rename to "):])
+		case strings.HasPrefix(line, "@@ "):
+			inHeader = false
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+
+			current.OldRanges = append(current.OldRanges, LineRange{Start: uint(h.oldStart), Lines: uint(h.oldLines)})
+			current.NewRanges = append(current.NewRanges, LineRange{Start: uint(h.newStart), Lines: uint(h.newLines)})
+			current.Added += uint(h.newLines)
+			current.Removed += uint(h.oldLines)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read git diff: %w", err)
+	}
+	finish()
+
+	return result, nil
+}
+
+// headerPath extracts path from "a/path b/path" of diff header, paths of renames are read from extended headers
+func headerPath(paths string) string {
+	if strings.HasPrefix(paths, `"`) {
+		if idx := strings.Index(paths, `" "`); idx >= 0 {
+			return strings.TrimPrefix(unquotePath(paths[:idx+1]), "a/")
+		}
+	}
+
+	half := (len(paths) - 1) / 2
+	if len(paths)%2 == 1 && paths[half] == ' ' && strings.HasPrefix(paths, "a/") &&
+		paths[half+1:] == "b/"+paths[2:half] {
+		return paths[2:half]
+	}
+
+	return ""
+}
+
+// gitlinkMode is tree entry mode of submodule, its commit is not stored in repository
+const gitlinkMode = "160000"
+
+// ExportFiles writes content of files at revision to dir keeping their relative paths.
+// Files are read by a single git cat-file process, submodules are skipped.
+func ExportFiles(ctx context.Context, repoPath, rev string, paths []string, dir string) error {
+	blobs, err := listBlobs(ctx, repoPath, rev)
+	if err != nil {
+		return err
+	}
+
+	objects := make([]string, 0, len(paths))
+	exported := make([]string, 0, len(paths))
+	for _, path := range paths {
+		object, found := blobs[path]
+		if !found {
+			return fmt.Errorf("failed to read %s at %s: file not found", path, rev)
+		}
+		if object == "" {
+			continue
+		}
+
+		objects = append(objects, object)
+		exported = append(exported, path)
+	}
+
+	if len(objects) == 0 {
+		return nil
+	}
+
+	gitCmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	gitCmd.Dir = repoPath
+	gitCmd.Stdin = strings.NewReader(strings.Join(objects, "\n") + "\n")
+
+	return streamCommand(ctx, gitCmd, func(r io.Reader) error {
+		reader := bufio.NewReader(r)
+		for _, path := range exported {
+			if err := writeBlob(reader, filepath.Join(dir, filepath.FromSlash(path))); err != nil {
+				return fmt.Errorf("failed to read %s at %s: %w", path, rev, err)
+			}
+		}
+		return nil
+	})
+}
+
+// listBlobs maps paths of files at revision to their objects, submodules are mapped to empty object
+func listBlobs(ctx context.Context, repoPath, rev string) (map[string]string, error) {
+	gitCmd := exec.CommandContext(ctx, "git", "ls-tree", "-r", "-z", "--full-tree", rev)
+	gitCmd.Dir = repoPath
+	output, err := gitCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files at %s: %v", rev, err)
+	}
+
+	blobs := make(map[string]string)
+	for _, entry := range strings.Split(string(output), "\x00") {
+		// Entry is "<mode> <type> <object>\t<path>"
+		info, path, found := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if !found || len(fields) != 3 {
+			continue
+		}
+
+		if fields[0] == gitlinkMode {
+			blobs[path] = ""
+		} else {
+			blobs[path] = fields[2]
+		}
+	}
+
+	return blobs, nil
+}
+
+// writeBlob reads one "<object> <type> <size>" header with content from git cat-file --batch output to target
+func writeBlob(r *bufio.Reader, target string) error {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return fmt.Errorf("unexpected object %q", strings.TrimSpace(header))
+	}
+
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid object size %q", fields[2])
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(file, r, size); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	// Content is followed by newline
+	_, err = r.Discard(1)
+	return err
+}
+
+// gitOutput runs git command in repository and returns its trimmed output
+func gitOutput(ctx context.Context, repoPath string, args ...string) (string, error) {
+	gitCmd := exec.CommandContext(ctx, "git", args...)
+	gitCmd.Dir = repoPath
+	output, err := gitCmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to execute git command: %v", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiff(t *testing.T) {
+	// Header lines of "a b.go" end with TAB as git writes paths with spaces
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3,0 +4,2 @@ func main() {
++	a := 1
++	b := 2
+@@ -10 +12 @@ func other() {
+--- removed line looking like a header
++++ added line looking like a header
+diff --git a/old name.go b/new name.go
+similarity index 100%
+rename from old name.go
+rename to new name.go
+diff --git a/added.go b/added.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/added.go
+@@ -0,0 +1,3 @@
++package main
++
++func added() {}
+diff --git a/deleted.go b/deleted.go
+deleted file mode 100644
+index 4444444..0000000
+--- a/deleted.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package main
+-func deleted() {}
+diff --git a/script.sh b/script.sh
+old mode 100644
+new mode 100755
+diff --git a/a b.go b/a b.go
+index 5555555..6666666 100644
+--- a/a b.go	
++++ b/a b.go	
+@@ -1 +1 @@
+-package a
++package b
+`
+
+	got, err := parseDiff(strings.NewReader(diff))
+	require.NoError(t, err)
+
+	assert.Equal(t, []FileDiff{
+		{
+			Path: "main.go", OldPath: "main.go", Added: 3, Removed: 1,
+			OldRanges: []LineRange{{Start: 3, Lines: 0}, {Start: 10, Lines: 1}},
+			NewRanges: []LineRange{{Start: 4, Lines: 2}, {Start: 12, Lines: 1}},
+		},
+		{Path: "new name.go", OldPath: "old name.go"},
+		{
+			Path: "added.go", Added: 3,
+			OldRanges: []LineRange{{Start: 0, Lines: 0}},
+			NewRanges: []LineRange{{Start: 1, Lines: 3}},
+		},
+		{
+			OldPath: "deleted.go", Removed: 2,
+			OldRanges: []LineRange{{Start: 1, Lines: 2}},
+			NewRanges: []LineRange{{Start: 0, Lines: 0}},
+		},
+		{Path: "script.sh", OldPath: "script.sh"},
+		{
+			Path: "a b.go", OldPath: "a b.go", Added: 1, Removed: 1,
+			OldRanges: []LineRange{{Start: 1, Lines: 1}},
+			NewRanges: []LineRange{{Start: 1, Lines: 1}},
+		},
+	}, got)
+
+	assert.Equal(t, []string{"modified", "renamed", "added", "deleted", "modified", "modified"},
+		[]string{got[0].Status(), got[1].Status(), got[2].Status(), got[3].Status(), got[4].Status(), got[5].Status()})
+}
+
+func TestLineRangeOverlaps(t *testing.T) {
+	assert.True(t, LineRange{Start: 4, Lines: 2}.Overlaps(5, 10))
+	assert.False(t, LineRange{Start: 4, Lines: 2}.Overlaps(6, 10))
+	assert.False(t, LineRange{Start: 10, Lines: 1}.Overlaps(5, 10))
+
+	// Deletion after line 7
+	assert.True(t, LineRange{Start: 7, Lines: 0}.Overlaps(5, 10))
+	assert.True(t, LineRange{Start: 0, Lines: 0}.Overlaps(1, 2))
+	assert.False(t, LineRange{Start: 10, Lines: 0}.Overlaps(5, 10))
+}
+
+func TestExportFiles(t *testing.T) {
+	repoDir := t.TempDir()
+	Unbundle(t, "../../test/bundles/churn-test.bundle", repoDir)
+
+	// Submodule is committed as gitlink, its commit is missing in repository
+	for _, args := range [][]string{
+		{"update-index", "--add", "--cacheinfo", "160000,1111111111111111111111111111111111111111,lib/sub"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "add submodule"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		require.NoError(t, cmd.Run())
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, ExportFiles(context.Background(), repoDir, "HEAD", []string{"main.go", "lib/sub", "Readme.md"}, dir))
+
+	for _, path := range []string{"main.go", "Readme.md"} {
+		expected, err := os.ReadFile(filepath.Join(repoDir, path))
+		require.NoError(t, err)
+		actual, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual), path)
+	}
+	assert.NoFileExists(t, filepath.Join(dir, "lib", "sub"))
+
+	err := ExportFiles(context.Background(), repoDir, "HEAD", []string{"missing.go"}, t.TempDir())
+	assert.ErrorContains(t, err, "missing.go")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, ExportFiles(ctx, repoDir, "HEAD", []string{"main.go"}, t.TempDir()))
+}
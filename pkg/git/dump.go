@@ -0,0 +1,221 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Name of log dump that is read from standard input
+const stdinDump = "-"
+
+// readLogDump reads commits from output of git log --pretty=format:%H --numstat saved to FromLog file.
+// Filters that git applies in live mode are applied to the dump where it is possible.
+// Reading stops when ctx is done, even if it waits for standard input.
+func readLogDump(ctx context.Context, opts ChurnOptions, fn func(*commit) error) error {
+	var r io.Reader = os.Stdin
+	if opts.FromLog != stdinDump {
+		f, err := os.Open(opts.FromLog)
+		if err != nil {
+			return fmt.Errorf("error opening git log dump: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	prefix := ""
+	if opts.Path != "" && opts.Path != "." {
+		prefix = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(opts.Path)), "/") + "/"
+	}
+
+	count := 0
+	parse := func() error {
+		return parseLogDump(ctx, r, func(c *commit) error {
+			count++
+			if opts.CommitCount > 0 && count > opts.CommitCount {
+				return nil
+			}
+
+			if prefix != "" {
+				files := c.Files[:0]
+				for _, change := range c.Files {
+					if strings.HasPrefix(change.Path, prefix) {
+						files = append(files, change)
+					}
+				}
+				c.Files = files
+			}
+
+			return fn(c)
+		})
+	}
+
+	// Read blocks until input is available, so it runs aside to return as soon as ctx is done
+	done := make(chan error, 1)
+	go func() { done <- parse() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("reading git log dump is interrupted: %w", ctx.Err())
+	}
+}
+
+// parseLogDump reads output of git log --pretty=format:%H --numstat and calls fn for every commit.
+// Every commit starts with a line of its hash followed by numstat lines, commits are separated by empty lines.
+func parseLogDump(ctx context.Context, r io.Reader, fn func(*commit) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var current *commit
+	line := 0
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return fmt.Errorf("reading git log dump is interrupted: %w", ctx.Err())
+		}
+
+		line++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if isCommitHash(strings.TrimSpace(text)) {
+			if current != nil {
+				if err := fn(current); err != nil {
+					return err
+				}
+			}
+			current = &commit{Hash: strings.TrimSpace(text)}
+			continue
+		}
+
+		if current == nil {
+			return fmt.Errorf("invalid git log dump at line %d: expected commit hash, got %q", line, text)
+		}
+
+		change, err := parseDumpRecord(text)
+		if err != nil {
+			return fmt.Errorf("invalid git log dump at line %d: %w", line, err)
+		}
+		current.Files = append(current.Files, change)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read git log dump: %w", err)
+	}
+
+	if current != nil {
+		return fn(current)
+	}
+
+	return nil
+}
+
+// parseDumpRecord parses numstat line "added\tdeleted\tpath" where path of renamed file is
+// written as "old => new" or "prefix/{old => new}/suffix" and special paths are quoted by git
+func parseDumpRecord(record string) (fileChange, error) {
+	parts := strings.SplitN(record, "\t", 3)
+	if len(parts) != 3 {
+		return fileChange{}, fmt.Errorf("invalid numstat record %q", record)
+	}
+
+	var change fileChange
+	if parts[0] == "-" && parts[1] == "-" {
+		change.Binary = true
+	} else {
+		added, errAdded := strconv.Atoi(parts[0])
+		removed, errRemoved := strconv.Atoi(parts[1])
+		if errAdded != nil || errRemoved != nil {
+			return fileChange{}, fmt.Errorf("invalid numstat record %q", record)
+		}
+		change.Added, change.Removed = added, removed
+	}
+
+	var err error
+	if change.OldPath, change.Path, err = parseDumpPath(parts[2]); err != nil {
+		return fileChange{}, fmt.Errorf("invalid path in numstat record %q: %w", record, err)
+	}
+
+	return change, nil
+}
+
+// parseDumpPath returns old and new path of numstat path, old path is empty if file is not renamed
+func parseDumpPath(field string) (string, string, error) {
+	if strings.HasPrefix(field, `"`) {
+		// Quoted paths of renamed file are written in full, without braces
+		oldPath, rest, err := cutQuotedPath(field)
+		if err != nil || rest == "" {
+			return "", oldPath, err
+		}
+
+		newPath, ok := strings.CutPrefix(rest, " => ")
+		if !ok {
+			return "", "", fmt.Errorf("unexpected %q after quoted path", rest)
+		}
+
+		newPath, err = decodeDumpPath(newPath)
+		return oldPath, newPath, err
+	}
+
+	start, end := strings.Index(field, "{"), strings.LastIndex(field, "}")
+	if start >= 0 && end > start {
+		if oldMiddle, newMiddle, ok := strings.Cut(field[start+1:end], " => "); ok {
+			prefix, suffix := field[:start], field[end+1:]
+			return joinRenamed(prefix, oldMiddle, suffix), joinRenamed(prefix, newMiddle, suffix), nil
+		}
+	}
+
+	if oldPath, newPath, ok := strings.Cut(field, " => "); ok {
+		newPath, err := decodeDumpPath(newPath)
+		return oldPath, newPath, err
+	}
+
+	return "", field, nil
+}
+
+// joinRenamed joins parts of path around braces, empty middle like "dir/{ => sub}/file" leaves double slash
+func joinRenamed(prefix, middle, suffix string) string {
+	if middle == "" && strings.HasSuffix(prefix, "/") && strings.HasPrefix(suffix, "/") {
+		return prefix + suffix[1:]
+	}
+	return prefix + middle + suffix
+}
+
+// decodeDumpPath decodes path that is either quoted as a whole or not quoted at all
+func decodeDumpPath(path string) (string, error) {
+	if !strings.HasPrefix(path, `"`) {
+		return path, nil
+	}
+
+	decoded, rest, err := cutQuotedPath(path)
+	if err == nil && rest != "" {
+		err = fmt.Errorf("unexpected %q after quoted path", rest)
+	}
+	return decoded, err
+}
+
+// cutQuotedPath decodes C-style quoted path at the start of s and returns the rest of s
+func cutQuotedPath(s string) (string, string, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			path, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("invalid quoted path %s", s[:i+1])
+			}
+			return path, s[i+1:], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated quoted path %s", s)
+}
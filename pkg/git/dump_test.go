@@ -0,0 +1,125 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const logDump = `9f8ac0c0c2c3a2b8d1e4f5a6b7c8d9e0f1a2b3c4
+3	1	src/{util => common}/strings.go
+2	0	main.go
+
+5d2b1c8a9e7f6d5c4b3a2918f7e6d5c4b3a29180
+-	-	logo.png
+4	2	src/util/strings.go
+1	1	"docs/\303\274ber.md"
+
+0a1b2c3d4e5f60718293a4b5c6d7e8f901234567
+10	0	main.go
+`
+
+func TestParseDumpPath(t *testing.T) {
+	tests := []struct {
+		field   string
+		oldPath string
+		path    string
+	}{
+		{field: "main.go", path: "main.go"},
+		{field: "sp ace.txt", path: "sp ace.txt"},
+		{field: "old.go => new.go", oldPath: "old.go", path: "new.go"},
+		{field: "src/{x => y}/f.go", oldPath: "src/x/f.go", path: "src/y/f.go"},
+		{field: "src/{ => d}/g", oldPath: "src/g", path: "src/d/g"},
+		{field: "{a => b}/f.go", oldPath: "a/f.go", path: "b/f.go"},
+		{field: `"t\tab.txt"`, path: "t\tab.txt"},
+		{field: `"\303\274.txt" => "z/\303\274.txt"`, oldPath: "ü.txt", path: "z/ü.txt"},
+		{field: `a.txt => "\303\274.txt"`, oldPath: "a.txt", path: "ü.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			oldPath, path, err := parseDumpPath(tt.field)
+			require.NoError(t, err)
+			assert.Equal(t, tt.oldPath, oldPath)
+			assert.Equal(t, tt.path, path)
+		})
+	}
+
+	_, _, err := parseDumpPath(`"unterminated`)
+	assert.Error(t, err)
+}
+
+func TestParseLogDump(t *testing.T) {
+	commits := make([]*commit, 0)
+	require.NoError(t, parseLogDump(context.Background(), strings.NewReader(logDump), func(c *commit) error {
+		commits = append(commits, c)
+		return nil
+	}))
+
+	require.Len(t, commits, 3)
+	assert.Equal(t, "9f8ac0c0c2c3a2b8d1e4f5a6b7c8d9e0f1a2b3c4", commits[0].Hash)
+	assert.Equal(t, []fileChange{
+		{OldPath: "src/util/strings.go", Path: "src/common/strings.go", Added: 3, Removed: 1},
+		{Path: "main.go", Added: 2},
+	}, commits[0].Files)
+	assert.Equal(t, fileChange{Path: "logo.png", Binary: true}, commits[1].Files[0])
+	assert.Equal(t, "docs/über.md", commits[1].Files[2].Path)
+
+	err := parseLogDump(context.Background(), strings.NewReader("1\t2\tmain.go\n"), func(*commit) error { return nil })
+	assert.ErrorContains(t, err, "line 1")
+
+	err = parseLogDump(context.Background(), strings.NewReader(logDump+"x\t1\tmain.go\n"), func(*commit) error { return nil })
+	assert.ErrorContains(t, err, "line 12")
+}
+
+func TestReadChurnFromLog(t *testing.T) {
+	dump := filepath.Join(t.TempDir(), "git.log")
+	require.NoError(t, os.WriteFile(dump, []byte(logDump), 0o644))
+
+	result, err := ReadChurn(context.Background(), "", ChurnOptions{SortBy: Changes, Top: -1, FromLog: dump})
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+
+	// Renamed file is counted under its current path
+	assert.Equal(t, "main.go", result[0].File)
+	assert.Equal(t, uint(12), result[0].Churn)
+	assert.Equal(t, "src/common/strings.go", result[1].File)
+	assert.Equal(t, uint(10), result[1].Churn)
+	assert.Equal(t, uint(2), result[1].Commits)
+
+	result, err = ReadChurn(context.Background(), "", ChurnOptions{SortBy: Changes, Top: -1, FromLog: dump, Path: "src", CommitCount: 2})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "src/common/strings.go", result[0].File)
+
+	_, err = ReadChurn(context.Background(), "", ChurnOptions{FromLog: filepath.Join(t.TempDir(), "missing.log")})
+	assert.ErrorContains(t, err, "error opening git log dump")
+}
+
+func TestReadLogDumpInterrupted(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer w.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin })
+
+	// Nothing is written to standard input, reading stops by ctx
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = readLogDump(ctx, ChurnOptions{FromLog: stdinDump}, func(*commit) error { return nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = parseLogDump(ctx, strings.NewReader(logDump), func(*commit) error { return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,178 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ExcludedCommit is a commit which changes are not counted in churn
+type ExcludedCommit struct {
+	Hash   string `json:"hash"`
+	Reason string `json:"reason"`
+}
+
+// commitFilter drops bulk-change commits, e.g. reformatting, mass renames or vendoring,
+// and commits of filtered out authors, and remembers why
+type commitFilter struct {
+	ignored  map[string]bool
+	maxFiles int
+	// Authors are normalized by mailmap before they are matched
+	mailmap       mailmap
+	author        *regexp.Regexp
+	excludeAuthor *regexp.Regexp
+	excluded      []ExcludedCommit
+}
+
+func newCommitFilter(repoPath string, opts ChurnOptions) (*commitFilter, error) {
+	filter := &commitFilter{maxFiles: opts.MaxFilesPerCommit, mailmap: mailmap{}}
+
+	if opts.IgnoreRevsFile != "" {
+		ignored, err := readIgnoreRevs(opts.IgnoreRevsFile)
+		if err != nil {
+			return nil, err
+		}
+		filter.ignored = ignored
+	}
+
+	if repoPath != "" {
+		m, err := readMailmap(filepath.Join(repoPath, ".mailmap"))
+		if err != nil {
+			return nil, err
+		}
+		filter.mailmap = m
+	}
+
+	var err error
+	if opts.Author != "" {
+		if filter.author, err = regexp.Compile(opts.Author); err != nil {
+			return nil, fmt.Errorf("invalid author pattern: %w", err)
+		}
+	}
+	if opts.ExcludeAuthor != "" {
+		if filter.excludeAuthor, err = regexp.Compile(opts.ExcludeAuthor); err != nil {
+			return nil, fmt.Errorf("invalid exclude-author pattern: %w", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// wrap passes to fn only commits that are not excluded, with authors normalized by mailmap.
+// Renames of excluded commits are still recorded to renames if it is not nil,
+// so history of files renamed by excluded commit is not split between old and new paths.
+func (f *commitFilter) wrap(fn func(*commit) error, renames renameTracker) func(*commit) error {
+	return func(c *commit) error {
+		c, ok := f.keep(c)
+		if ok {
+			return fn(c)
+		}
+
+		if renames != nil {
+			for _, change := range c.Files {
+				renames.track(change.OldPath, change.Path)
+			}
+		}
+		return nil
+	}
+}
+
+// keep returns commit with normalized authors and whether it passes filter, excluded commits are remembered
+func (f *commitFilter) keep(c *commit) (*commit, bool) {
+	c = f.normalize(c)
+
+	if reason := f.reason(c); reason != "" {
+		f.excluded = append(f.excluded, ExcludedCommit{Hash: c.Hash, Reason: reason})
+		return c, false
+	}
+
+	return c, true
+}
+
+// normalize returns copy of commit with canonical authors and without excluded or repeated co-authors
+func (f *commitFilter) normalize(c *commit) *commit {
+	normalized := *c
+	normalized.Author = f.mailmap.resolve(c.Author)
+	normalized.CoAuthors = nil
+
+	seen := map[string]bool{normalized.Author.key(): true}
+	for _, coAuthor := range c.CoAuthors {
+		coAuthor = f.mailmap.resolve(coAuthor)
+		if seen[coAuthor.key()] || f.excludeAuthor != nil && f.excludeAuthor.MatchString(coAuthor.String()) {
+			continue
+		}
+		seen[coAuthor.key()] = true
+		normalized.CoAuthors = append(normalized.CoAuthors, coAuthor)
+	}
+
+	return &normalized
+}
+
+// reason returns why commit is excluded, empty string if it is not
+func (f *commitFilter) reason(c *commit) string {
+	if f.ignored[c.Hash] {
+		return "listed in ignore-revs file"
+	}
+
+	if f.excludeAuthor != nil && f.excludeAuthor.MatchString(c.Author.String()) {
+		return fmt.Sprintf("author %s matches excluded authors", c.Author)
+	}
+
+	if f.author != nil && !f.matchesAuthor(c) {
+		return fmt.Sprintf("neither author %s nor co-authors match authors", c.Author)
+	}
+
+	if f.maxFiles > 0 && len(c.Files) > f.maxFiles {
+		return fmt.Sprintf("changes %d files, more than %d", len(c.Files), f.maxFiles)
+	}
+
+	return ""
+}
+
+// matchesAuthor checks if author or any of co-authors of commit matches author pattern
+func (f *commitFilter) matchesAuthor(c *commit) bool {
+	if f.author.MatchString(c.Author.String()) {
+		return true
+	}
+
+	for _, coAuthor := range c.CoAuthors {
+		if f.author.MatchString(coAuthor.String()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readIgnoreRevs reads file in format of .git-blame-ignore-revs: one full commit hash per line,
+// comments start with # and empty lines are skipped
+func readIgnoreRevs(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening ignore-revs file: %w", err)
+	}
+	defer f.Close()
+
+	ignored := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		text = strings.ToLower(strings.TrimSpace(text))
+		if text == "" {
+			continue
+		}
+
+		if !isCommitHash(text) {
+			return nil, fmt.Errorf("invalid commit hash %q at line %d of %s", text, line, path)
+		}
+		ignored[text] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading ignore-revs file: %w", err)
+	}
+
+	return ignored, nil
+}
@@ -0,0 +1,147 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadIgnoreRevs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".git-blame-ignore-revs")
+	require.NoError(t, os.WriteFile(path, []byte(`# Reformat with clang-format
+9F8AC0C0C2C3A2B8D1E4F5A6B7C8D9E0F1A2B3C4
+
+0a1b2c3d4e5f60718293a4b5c6d7e8f901234567 # Vendor dependencies
+`), 0o644))
+
+	ignored, err := readIgnoreRevs(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		"9f8ac0c0c2c3a2b8d1e4f5a6b7c8d9e0f1a2b3c4": true,
+		"0a1b2c3d4e5f60718293a4b5c6d7e8f901234567": true,
+	}, ignored)
+
+	require.NoError(t, os.WriteFile(path, []byte("9f8ac0c\n"), 0o644))
+	_, err = readIgnoreRevs(path)
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestReadChurnExcluded(t *testing.T) {
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "git.log")
+	require.NoError(t, os.WriteFile(dump, []byte(logDump), 0o644))
+	revs := filepath.Join(dir, "ignore-revs")
+	require.NoError(t, os.WriteFile(revs, []byte("0a1b2c3d4e5f60718293a4b5c6d7e8f901234567\n"), 0o644))
+
+	opts := ChurnOptions{SortBy: Changes, Top: -1, FromLog: dump, IgnoreRevsFile: revs, MaxFilesPerCommit: 2, OutputFormat: JSON}
+	result, excluded, err := readChurn(context.Background(), "", opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []ExcludedCommit{
+		{Hash: "5d2b1c8a9e7f6d5c4b3a2918f7e6d5c4b3a29180", Reason: "changes 3 files, more than 2"},
+		{Hash: "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", Reason: "listed in ignore-revs file"},
+	}, excluded)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "src/common/strings.go", result[0].File)
+	assert.Equal(t, uint(4), result[0].Churn)
+
+	var buf bytes.Buffer
+	require.NoError(t, printStats(result, excluded, &buf, opts))
+
+	var output struct {
+		Metadata jsonMetadata `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &output))
+	assert.Equal(t, excluded, output.Metadata.ExcludedCommits)
+	assert.Equal(t, 2, output.Metadata.Filters.MaxFiles)
+}
+
+func TestCommitFilterAuthors(t *testing.T) {
+	repoPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, ".mailmap"), []byte("Alice Smith <alice@example.com>\n"), 0o644))
+
+	hashes := func(opts ChurnOptions) ([]string, []ExcludedCommit) {
+		filter, err := newCommitFilter(repoPath, opts)
+		require.NoError(t, err)
+
+		kept := make([]string, 0)
+		require.NoError(t, parseNumstat(strings.NewReader(numstatLog), filter.wrap(func(c *commit) error {
+			kept = append(kept, c.Hash)
+			return nil
+		}, nil)))
+		return kept, filter.excluded
+	}
+
+	kept, excluded := hashes(ChurnOptions{ExcludeAuthor: "^Bob "})
+	assert.Equal(t, []string{hash1, hash4}, kept)
+	assert.Equal(t, ExcludedCommit{Hash: hash2, Reason: "author Bob <bob@example.com> matches excluded authors"}, excluded[0])
+
+	// Co-authors are matched too
+	kept, _ = hashes(ChurnOptions{Author: "carol@"})
+	assert.Equal(t, []string{hash2}, kept)
+
+	// Authors are matched after mailmap, email of mailmap entry is case-insensitive
+	kept, excluded = hashes(ChurnOptions{Author: "^Alice Smith"})
+	assert.Equal(t, []string{hash1, hash4}, kept)
+	assert.Equal(t, "neither author Bob <bob@example.com> nor co-authors match authors", excluded[0].Reason)
+
+	_, err := newCommitFilter(repoPath, ChurnOptions{Author: "("})
+	assert.ErrorContains(t, err, "invalid author pattern")
+}
+
+func TestChurnAggregatorCoAuthors(t *testing.T) {
+	filter, err := newCommitFilter("", ChurnOptions{ExcludeAuthor: `\[bot\]`})
+	require.NoError(t, err)
+
+	aggregator := newChurnAggregator(ChurnOptions{Authors: true})
+	require.NoError(t, filter.wrap(aggregator.add, aggregator.renames)(&commit{
+		Hash:   hash1,
+		Author: author{Name: "Alice", Email: "alice@example.com"},
+		CoAuthors: []author{
+			{Name: "Bob", Email: "bob@example.com"},
+			{Name: "Alice", Email: "ALICE@example.com"},
+			{Name: "renovate[bot]", Email: "bot@example.com"},
+		},
+		Files: []fileChange{{Path: "main.go", Added: 4}},
+	}))
+
+	result := aggregator.result()
+	require.Len(t, result, 1)
+	assert.Equal(t, uint(2), result[0].Ownership.Authors)
+	assert.Equal(t, uint(1), result[0].Commits)
+}
+
+func TestReadChurnExcludedRename(t *testing.T) {
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "git.log")
+	require.NoError(t, os.WriteFile(dump, []byte(logDump), 0o644))
+	revs := filepath.Join(dir, "ignore-revs")
+	require.NoError(t, os.WriteFile(revs, []byte("9f8ac0c0c2c3a2b8d1e4f5a6b7c8d9e0f1a2b3c4\n"), 0o644))
+
+	// Commit renaming src/util/strings.go is excluded, older changes still go to the current path
+	result, excluded, err := readChurn(context.Background(), "", ChurnOptions{SortBy: Changes, Top: -1, FromLog: dump, IgnoreRevsFile: revs})
+	require.NoError(t, err)
+	require.Len(t, excluded, 1)
+
+	churn := make(map[string]uint)
+	for _, chunk := range result {
+		churn[chunk.File] = chunk.Churn
+	}
+	assert.Equal(t, map[string]uint{"src/common/strings.go": 6, "main.go": 10, "docs/über.md": 2}, churn)
+
+	coupling, err := ReadCoupling(context.Background(), "", ChurnOptions{Top: -1, FromLog: dump, IgnoreRevsFile: revs}, CouplingOptions{MinRevisions: 1, MinSharedCommits: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, coupling)
+	for _, pair := range coupling {
+		assert.NotEqual(t, "src/util/strings.go", pair.File)
+		assert.NotEqual(t, "src/util/strings.go", pair.Coupled)
+	}
+}
@@ -0,0 +1,380 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vbvictor/ccv/pkg/complexity"
+)
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// hunk is a changed region of a file in unified diff with zero context lines
+type hunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+}
+
+func PrintFunctionStats(ctx context.Context, repoPath string, files complexity.FilesStat) error {
+	churns, excluded, err := readFunctionChurn(ctx, repoPath, ChurnOpts, files)
+	if err != nil {
+		return fmt.Errorf("error getting function churn metrics: %w", err)
+	}
+
+	return printFunctionStats(churns, excluded, os.Stdout, ChurnOpts)
+}
+
+// ReadFunctionChurn attributes lines changed by every commit to functions of files.
+// Paths of files must be relative to repository root. Line numbers of older commits are shifted
+// by changes made after them, so they are matched against current function ranges.
+func ReadFunctionChurn(ctx context.Context, repoPath string, opts ChurnOptions, files complexity.FilesStat) ([]*complexity.FunctionChurn, error) {
+	churns, _, err := readFunctionChurn(ctx, repoPath, opts, files)
+	return churns, err
+}
+
+// readFunctionChurn reads churn of functions and commits excluded from it
+func readFunctionChurn(ctx context.Context, repoPath string, opts ChurnOptions, files complexity.FilesStat) ([]*complexity.FunctionChurn, []ExcludedCommit, error) {
+	filter, err := newCommitFilter(repoPath, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gitCmd, err := logCommand(ctx, repoPath, opts, "-c", "core.quotePath=false", "log", prettyFormat(), "--patch", "--unified=0", "-M", "--no-color", "--no-ext-diff")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result []*complexity.FunctionChurn
+	err = streamCommand(ctx, gitCmd, func(r io.Reader) error {
+		result, err = parseFunctionChurn(r, files, opts, filter)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sortAndLimitFunctions(result, opts.SortBy, opts.Top), filter.excluded, nil
+}
+
+// functionChurnParser keeps state of patch parsing of commits ordered from newest to oldest
+type functionChurnParser struct {
+	opts      ChurnOptions
+	functions map[string][]*complexity.FunctionChurn // Sorted by line
+	// Hunks of already processed (newer) commits for every current path
+	transforms map[string][][]hunk
+	renames    renameTracker
+
+	touched          map[*complexity.FunctionChurn]bool
+	oldPath, newPath string
+	hunks            []hunk
+	// Current commit and its changed files, commit is attributed when all its files are read
+	commit  *commit
+	pending []filePatch
+	filter  *commitFilter
+	// Weight of changes of the current commit
+	weight   float64
+	now      time.Time
+	progress *progress
+}
+
+// filePatch is a file changed by commit with its hunks
+type filePatch struct {
+	oldPath, newPath string
+	hunks            []hunk
+}
+
+func parseFunctionChurn(r io.Reader, files complexity.FilesStat, opts ChurnOptions, filter *commitFilter) ([]*complexity.FunctionChurn, error) {
+	p := &functionChurnParser{
+		opts:       opts,
+		functions:  make(map[string][]*complexity.FunctionChurn),
+		transforms: make(map[string][][]hunk),
+		renames:    make(renameTracker),
+		touched:    make(map[*complexity.FunctionChurn]bool),
+		filter:     filter,
+		weight:     1,
+		now:        time.Now(),
+		progress:   newProgress(opts.Progress),
+	}
+	defer p.progress.done()
+
+	for _, file := range files {
+		for _, fn := range file.Functions {
+			p.functions[file.Path] = append(p.functions[file.Path], &complexity.FunctionChurn{
+				ChurnChunk: complexity.ChurnChunk{File: file.Path},
+				Package:    fn.Package,
+				Name:       fn.Name,
+				Line:       fn.Line,
+				Length:     fn.Length,
+			})
+		}
+		sort.SliceStable(p.functions[file.Path], func(i, j int) bool {
+			return p.functions[file.Path][i].Line < p.functions[file.Path][j].Line
+		})
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	inHeader := false
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			p.finishFile()
+			inHeader = true
+		case inHeader && strings.HasPrefix(line, "--- "):
+			p.oldPath = diffPath(line[4:], "a/")
+		case inHeader && strings.HasPrefix(line, "+++ "):
+			p.newPath = diffPath(line[4:], "b/")
+		case inHeader && strings.HasPrefix(line, "rename from "):
+			p.oldPath = unquotePath(line[len("rename from "):])
+		case inHeader && strings.HasPrefix(line, "rename to "):
+			p.newPath = unquotePath(line[len("rename to "):])
+		case strings.HasPrefix(line, "@@ "):
+			inHeader = false
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			p.hunks = append(p.hunks, h)
+		case isCommitLine(line):
+			p.finishCommit()
+			c, err := newCommit(strings.Split(line, "\x00"))
+			if err != nil {
+				return nil, err
+			}
+			p.commit = c
+			p.progress.commit()
+			inHeader = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read git log: %w", err)
+	}
+	p.finishCommit()
+
+	result := make([]*complexity.FunctionChurn, 0)
+	for _, functions := range p.functions {
+		for _, fn := range functions {
+			if fn.Commits > 0 {
+				if p.opts.HalfLife > 0 {
+					fn.WeightedChurn = math.Round(fn.WeightedChurn*100) / 100
+				} else {
+					fn.WeightedChurn = 0
+				}
+				result = append(result, fn)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// finishFile adds the current file to changes of commit
+func (p *functionChurnParser) finishFile() {
+	if p.oldPath != "" || p.newPath != "" {
+		p.pending = append(p.pending, filePatch{oldPath: p.oldPath, newPath: p.newPath, hunks: p.hunks})
+	}
+	p.oldPath, p.newPath, p.hunks = "", "", nil
+}
+
+// finishCommit attributes changes of the current commit to functions unless the commit is excluded.
+// Changes of excluded commits still shift lines of older commits.
+func (p *functionChurnParser) finishCommit() {
+	p.finishFile()
+	defer func() {
+		p.commit, p.pending = nil, nil
+		p.touched = make(map[*complexity.FunctionChurn]bool)
+	}()
+
+	attribute := true
+	if p.commit != nil {
+		c := *p.commit
+		for _, patch := range p.pending {
+			c.Files = append(c.Files, fileChange{Path: patch.newPath, OldPath: patch.oldPath})
+		}
+
+		_, attribute = p.filter.keep(&c)
+		p.weight = decayWeight(c.Time, p.now, p.opts.HalfLife)
+	}
+
+	for _, patch := range p.pending {
+		p.applyPatch(patch, attribute)
+	}
+}
+
+// applyPatch attributes hunks of file to functions if attribute is set and remembers them to shift older commits
+func (p *functionChurnParser) applyPatch(patch filePatch, attribute bool) {
+	if patch.newPath == "" { // File was deleted
+		return
+	}
+
+	current := p.renames.track(patch.oldPath, patch.newPath)
+
+	if !attribute || len(patch.hunks) == 0 || shouldSkipFile(current, p.opts.ExcludePath, p.opts.Extensions) {
+		p.transforms[current] = append(p.transforms[current], patch.hunks)
+		return
+	}
+
+	transforms := p.transforms[current]
+	toCurrent := func(line int) int {
+		for i := len(transforms) - 1; i >= 0; i-- {
+			line = shiftLine(line, transforms[i])
+		}
+		return line
+	}
+
+	for _, h := range patch.hunks {
+		for i := 0; i < h.newLines; i++ {
+			if fn := p.functionAt(current, toCurrent(h.newStart+i)); fn != nil {
+				fn.Added++
+				fn.Churn++
+				fn.WeightedChurn += p.weight
+				p.touch(fn)
+			}
+		}
+
+		if h.oldLines > 0 {
+			if fn := p.functionAt(current, toCurrent(max(h.newStart, 1))); fn != nil {
+				fn.Removed += uint(h.oldLines)
+				fn.Churn += uint(h.oldLines)
+				fn.WeightedChurn += p.weight * float64(h.oldLines)
+				p.touch(fn)
+			}
+		}
+	}
+
+	p.transforms[current] = append(p.transforms[current], patch.hunks)
+}
+
+func (p *functionChurnParser) touch(fn *complexity.FunctionChurn) {
+	if !p.touched[fn] {
+		p.touched[fn] = true
+		fn.Commits++
+	}
+}
+
+// functionAt returns function which range contains line, functions without length occupy single line
+func (p *functionChurnParser) functionAt(path string, line int) *complexity.FunctionChurn {
+	functions := p.functions[path]
+	idx := sort.Search(len(functions), func(i int) bool {
+		return int(functions[i].Line) > line
+	})
+
+	for i := idx - 1; i >= 0; i-- {
+		fn := functions[i]
+		if line < int(fn.Line)+max(int(fn.Length), 1) {
+			return fn
+		}
+	}
+
+	return nil
+}
+
+// shiftLine maps line number before the change described by hunks to line number after it.
+// Lines rewritten by the change are mapped to the start of the replacing region.
+func shiftLine(line int, hunks []hunk) int {
+	delta := 0
+
+	for _, h := range hunks {
+		if h.oldLines == 0 { // Pure insertion after oldStart
+			if h.oldStart >= line {
+				break
+			}
+			delta += h.newLines
+			continue
+		}
+
+		if line < h.oldStart {
+			break
+		}
+
+		if line < h.oldStart+h.oldLines {
+			if h.newLines == 0 {
+				return max(h.newStart, 1)
+			}
+			return h.newStart + min(line-h.oldStart, h.newLines-1)
+		}
+
+		delta += h.newLines - h.oldLines
+	}
+
+	return line + delta
+}
+
+func parseHunkHeader(line string) (hunk, error) {
+	matches := hunkHeaderRe.FindStringSubmatch(line)
+	if matches == nil {
+		return hunk{}, fmt.Errorf("invalid hunk header: %s", line)
+	}
+
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	oldStart, _ := strconv.Atoi(matches[1])
+	newStart, _ := strconv.Atoi(matches[3])
+
+	return hunk{
+		oldStart: oldStart,
+		oldLines: count(matches[2]),
+		newStart: newStart,
+		newLines: count(matches[4]),
+	}, nil
+}
+
+// diffPath extracts path from ---/+++ header lines, empty string means /dev/null.
+// Git ends header line with TAB if path contains a space.
+func diffPath(path, prefix string) string {
+	path = unquotePath(strings.TrimSuffix(path, "\t"))
+	if path == "/dev/null" {
+		return ""
+	}
+
+	return strings.TrimPrefix(path, prefix)
+}
+
+// unquotePath decodes paths that git quotes when they contain special characters
+func unquotePath(path string) string {
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			return unquoted
+		}
+	}
+
+	return path
+}
+
+// isCommitLine checks if line is a commit header in logFormat printed before patches of commit
+func isCommitLine(line string) bool {
+	hash, _, found := strings.Cut(line, "\x00")
+	return found && isCommitHash(hash) && strings.Count(line, "\x00") == len(logFormat)-1
+}
+
+func isCommitHash(line string) bool {
+	if len(line) != 40 {
+		return false
+	}
+
+	for _, c := range line {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+
+	return true
+}
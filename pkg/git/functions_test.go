@@ -0,0 +1,236 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbvictor/ccv/pkg/complexity"
+)
+
+func TestShiftLine(t *testing.T) {
+	hunks := []hunk{
+		{oldStart: 2, oldLines: 0, newStart: 3, newLines: 2},   // Insert 2 lines after line 2
+		{oldStart: 5, oldLines: 2, newStart: 7, newLines: 1},   // Replace lines 5-6 with one line
+		{oldStart: 10, oldLines: 3, newStart: 10, newLines: 0}, // Delete lines 10-12
+	}
+
+	tests := []struct {
+		line, want int
+	}{
+		{line: 1, want: 1},
+		{line: 2, want: 2},
+		{line: 3, want: 5},
+		{line: 5, want: 7},
+		{line: 6, want: 7},
+		{line: 7, want: 8},
+		{line: 9, want: 10},
+		{line: 11, want: 10},
+		{line: 13, want: 11},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, shiftLine(tt.line, hunks), "line %d", tt.line)
+	}
+}
+
+func TestParseHunkHeader(t *testing.T) {
+	h, err := parseHunkHeader("@@ -10,2 +12 @@ func main() {")
+	require.NoError(t, err)
+	assert.Equal(t, hunk{oldStart: 10, oldLines: 2, newStart: 12, newLines: 1}, h)
+
+	_, err = parseHunkHeader("@@ invalid @@")
+	assert.Error(t, err)
+}
+
+// commitHeader formats header of commit in logFormat as git log prints it before patches
+func commitHeader(hash, identity, timestamp string, coAuthors ...string) string {
+	a := parseAuthor(identity)
+	return strings.Join([]string{hash, a.Name, a.Email, strings.Join(coAuthors, "\x1f"), timestamp}, "\x00")
+}
+
+func TestParseFunctionChurn(t *testing.T) {
+	// Commits are listed from newest to oldest.
+	// The newest commit inserts two lines at the top of main.go, so older changes are shifted by two lines.
+	log := commitHeader("cccccccccccccccccccccccccccccccccccccccc", "Alice <alice@example.com>", "1706745600") + `
+diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -0,0 +1,2 @@
++// comment
++// comment
+@@ -8 +10 @@ func second() {
+-	return 1
++	return 2
+
+` + commitHeader("bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", "Bob <bob@example.com>", "1704067200", "Alice <alice@example.com>") + `
+diff --git a/old.go b/main.go
+similarity index 90%
+rename from old.go
+rename to main.go
+index 3333333..1111111 100644
+--- a/old.go
++++ b/main.go
+@@ -3,0 +4,2 @@ func first() {
++	a := 1
++	b := 2
+diff --git a/vendor/lib.go b/vendor/lib.go
+index 4444444..5555555 100644
+--- a/vendor/lib.go
++++ b/vendor/lib.go
+@@ -1 +1 @@
+--- removed line looking like a header
++++ added line looking like a header
+
+` + commitHeader("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "Carol <carol@example.com>", "1672531200") + `
+diff --git a/old.go b/old.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/old.go
+@@ -0,0 +1,6 @@
++package main
++
++func first() {
++}
++func second() {
++}
+`
+
+	files := complexity.FilesStat{
+		{
+			Path: "main.go",
+			Functions: complexity.FunctionsStat{
+				{File: "main.go", Name: "first", Line: 5, Length: 4},
+				{File: "main.go", Name: "second", Line: 9, Length: 2},
+			},
+		},
+		{
+			Path: "vendor/lib.go",
+			Functions: complexity.FunctionsStat{
+				{File: "vendor/lib.go", Name: "lib", Line: 1, Length: 3},
+			},
+		},
+	}
+
+	got, err := parseFunctionChurn(strings.NewReader(log), files, ChurnOptions{ExcludePath: "vendor/"}, &commitFilter{})
+	require.NoError(t, err)
+
+	require.Len(t, got, 2)
+
+	byName := make(map[string]complexity.ChurnChunk)
+	for _, fn := range got {
+		byName[fn.Name] = fn.ChurnChunk
+	}
+
+	assert.Equal(t, complexity.ChurnChunk{File: "main.go", Churn: 4, Added: 4, Removed: 0, Commits: 2}, byName["first"])
+	assert.Equal(t, complexity.ChurnChunk{File: "main.go", Churn: 4, Added: 3, Removed: 1, Commits: 2}, byName["second"])
+
+	// Weights of recent enough commits are close to 1
+	got, err = parseFunctionChurn(strings.NewReader(log), files, ChurnOptions{HalfLife: 1e9}, &commitFilter{})
+	require.NoError(t, err)
+	for _, fn := range got {
+		assert.Equal(t, float64(fn.Churn), fn.WeightedChurn, fn.Name)
+	}
+
+	// Excluded commit is not counted, but its changes still shift lines of older commits
+	filter := &commitFilter{ignored: map[string]bool{"cccccccccccccccccccccccccccccccccccccccc": true}, maxFiles: 1}
+	got, err = parseFunctionChurn(strings.NewReader(log), files, ChurnOptions{}, filter)
+	require.NoError(t, err)
+
+	byName = make(map[string]complexity.ChurnChunk)
+	for _, fn := range got {
+		byName[fn.Name] = fn.ChurnChunk
+	}
+	assert.Equal(t, complexity.ChurnChunk{File: "main.go", Churn: 2, Added: 2, Removed: 0, Commits: 1}, byName["first"])
+	assert.Equal(t, complexity.ChurnChunk{File: "main.go", Churn: 2, Added: 2, Removed: 0, Commits: 1}, byName["second"])
+	assert.Equal(t, []ExcludedCommit{
+		{Hash: "cccccccccccccccccccccccccccccccccccccccc", Reason: "listed in ignore-revs file"},
+		{Hash: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Reason: "changes 2 files, more than 1"},
+	}, filter.excluded)
+
+	// Commits are filtered by authors normalized by mailmap, co-authors match author filter too
+	repoPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, ".mailmap"), []byte("Alice <alice@example.com> <carol@example.com>\n"), 0o644))
+
+	filter, err = newCommitFilter(repoPath, ChurnOptions{Author: "^Alice ", ExcludeAuthor: "^Bob "})
+	require.NoError(t, err)
+	got, err = parseFunctionChurn(strings.NewReader(log), files, ChurnOptions{}, filter)
+	require.NoError(t, err)
+
+	byName = make(map[string]complexity.ChurnChunk)
+	for _, fn := range got {
+		byName[fn.Name] = fn.ChurnChunk
+	}
+	assert.Equal(t, complexity.ChurnChunk{File: "main.go", Churn: 2, Added: 2, Removed: 0, Commits: 1}, byName["first"])
+	assert.Equal(t, complexity.ChurnChunk{File: "main.go", Churn: 4, Added: 3, Removed: 1, Commits: 2}, byName["second"])
+	assert.Equal(t, []ExcludedCommit{
+		{Hash: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Reason: "author Bob <bob@example.com> matches excluded authors"},
+	}, filter.excluded)
+
+	filter, err = newCommitFilter(repoPath, ChurnOptions{Author: "^Bob "})
+	require.NoError(t, err)
+	got, err = parseFunctionChurn(strings.NewReader(log), files, ChurnOptions{ExcludePath: "vendor/"}, filter)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "first", got[0].Name)
+	assert.Len(t, filter.excluded, 2)
+
+	// Header lines of paths with spaces end with TAB, history of file is kept across rename
+	spaced := commitHeader("dddddddddddddddddddddddddddddddddddddddd", "Alice <alice@example.com>", "1706745600") + "\n" +
+		"diff --git a/a b.go b/c d.go\n" +
+		"similarity index 77%\n" +
+		"rename from a b.go\n" +
+		"rename to c d.go\n" +
+		"--- a/a b.go\t\n" +
+		"+++ b/c d.go\t\n" +
+		"@@ -3,0 +4 @@ func f() {\n" +
+		"+\treturn\n" +
+		"\n" +
+		commitHeader("eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", "Alice <alice@example.com>", "1704067200") + "\n" +
+		"diff --git a/a b.go b/a b.go\n" +
+		"new file mode 100644\n" +
+		"--- /dev/null\n" +
+		"+++ b/a b.go\t\n" +
+		"@@ -0,0 +1,4 @@\n" +
+		"+package main\n" +
+		"+\n" +
+		"+func f() {\n" +
+		"+}\n"
+
+	files = complexity.FilesStat{
+		{Path: "c d.go", Functions: complexity.FunctionsStat{{File: "c d.go", Name: "f", Line: 3, Length: 3}}},
+	}
+
+	got, err = parseFunctionChurn(strings.NewReader(spaced), files, ChurnOptions{}, &commitFilter{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, complexity.ChurnChunk{File: "c d.go", Churn: 3, Added: 3, Removed: 0, Commits: 2}, got[0].ChurnChunk)
+}
+
+func TestPrintFunctionTable(t *testing.T) {
+	var buf strings.Builder
+
+	results := []*complexity.FunctionChurn{
+		{
+			ChurnChunk: complexity.ChurnChunk{File: "main.go", Churn: 20, Added: 15, Removed: 5, Commits: 3},
+			Package:    []string{"Server"},
+			Name:       "Handle",
+			Line:       12,
+		},
+	}
+
+	printFunctionTable(results, &buf, ChurnOptions{Top: 1, SortBy: Changes})
+
+	expected := "\nTop 1 most modified functions (by changes):\n" +
+		strings.Repeat("-", 100) + "\n" +
+		"CHANGES  ADDED    DELETED  COMMITS  FUNCTION                       FILEPATH\n" +
+		strings.Repeat("-", 100) + "\n" +
+		"20       15       5        3        Server::Handle                 main.go:12\n"
+
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,225 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format of commit header passed to git log --pretty, fields are separated by NUL.
+// Co-authors are read from Co-authored-by trailers separated by unit separator, so header is a single line.
+var logFormat = []string{"%H", "%an", "%ae", "%(trailers:key=Co-authored-by,valueonly,unfold,separator=%x1F)", "%ct"}
+
+// fileChange is a numstat record of a single file modified in commit
+type fileChange struct {
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"` // Previous path of renamed file, empty otherwise
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// author identifies person who made a commit
+type author struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// key returns identity used to group changes of the same person
+func (a author) key() string {
+	if a.Email != "" {
+		return strings.ToLower(a.Email)
+	}
+	return a.Name
+}
+
+// String formats author as git does, "Name <email>"
+func (a author) String() string {
+	return fmt.Sprintf("%s <%s>", a.Name, a.Email)
+}
+
+// parseAuthor parses identity "Name <email>", text without email is a name
+func parseAuthor(identity string) author {
+	identity = strings.TrimSpace(identity)
+
+	start, end := strings.LastIndex(identity, "<"), strings.LastIndex(identity, ">")
+	if start < 0 || end < start {
+		return author{Name: identity}
+	}
+
+	return author{Name: strings.TrimSpace(identity[:start]), Email: strings.TrimSpace(identity[start+1 : end])}
+}
+
+type commit struct {
+	Hash   string `json:"hash"`
+	Author author `json:"author"`
+	// Authors of Co-authored-by trailers
+	CoAuthors []author `json:"co_authors,omitempty"`
+	// Committer date, commits are listed in order of history so dates are not always sorted
+	Time  time.Time    `json:"time"`
+	Files []fileChange `json:"files,omitempty"`
+}
+
+func prettyFormat() string {
+	return "--pretty=format:" + strings.Join(logFormat, "%x00")
+}
+
+// newCommit creates commit from header fields in logFormat order
+func newCommit(fields []string) (*commit, error) {
+	hash := strings.TrimSpace(fields[0])
+	if !isCommitHash(hash) {
+		return nil, fmt.Errorf("invalid commit hash %q", hash)
+	}
+
+	timestamp, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit date %q of %s", fields[4], hash)
+	}
+
+	c := &commit{
+		Hash:   hash,
+		Author: author{Name: fields[1], Email: fields[2]},
+		Time:   time.Unix(timestamp, 0).UTC(),
+	}
+
+	for _, identity := range strings.Split(fields[3], "\x1f") {
+		if strings.TrimSpace(identity) != "" {
+			c.CoAuthors = append(c.CoAuthors, parseAuthor(identity))
+		}
+	}
+
+	return c, nil
+}
+
+// parseNumstat reads output of git log --numstat -z and calls fn for every commit.
+// Header of commit is terminated by newline that is followed by the first numstat record,
+// records are terminated by NUL and commit with changes ends with an empty record.
+// Renamed files are written as "added\tdeleted\t\0old\0new\0".
+func parseNumstat(r io.Reader, fn func(*commit) error) error {
+	br := bufio.NewReader(r)
+
+	eof := false
+	next := func() (string, error) {
+		token, err := br.ReadString(0)
+		if err == io.EOF {
+			eof = true
+			return token, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read git log: %w", err)
+		}
+		return token[:len(token)-1], nil
+	}
+
+	for !eof {
+		fields := make([]string, 0, len(logFormat))
+		record := ""
+		hasFiles := false
+
+		for i := 0; i < len(logFormat); i++ {
+			token, err := next()
+			if err != nil {
+				return err
+			}
+
+			if i == 0 {
+				token = strings.TrimLeft(token, "\n")
+				if eof && token == "" {
+					return nil
+				}
+			}
+
+			if i == len(logFormat)-1 {
+				if idx := strings.IndexByte(token, '\n'); idx >= 0 {
+					token, record = token[:idx], token[idx+1:]
+					hasFiles = true
+				}
+			}
+
+			if eof && i != len(logFormat)-1 {
+				return fmt.Errorf("unexpected end of git log in commit header")
+			}
+
+			fields = append(fields, token)
+		}
+
+		c, err := newCommit(fields)
+		if err != nil {
+			return err
+		}
+
+		for hasFiles && record != "" {
+			change, err := parseNumstatRecord(record, next)
+			if err != nil {
+				return err
+			}
+			c.Files = append(c.Files, change)
+
+			if record, err = next(); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseNumstatRecord(record string, next func() (string, error)) (fileChange, error) {
+	parts := strings.SplitN(record, "\t", 3)
+	if len(parts) != 3 {
+		return fileChange{}, fmt.Errorf("invalid numstat record %q", record)
+	}
+
+	change := fileChange{Path: parts[2]}
+
+	if parts[0] == "-" && parts[1] == "-" {
+		change.Binary = true
+	} else {
+		added, errAdded := strconv.Atoi(parts[0])
+		removed, errRemoved := strconv.Atoi(parts[1])
+		if errAdded != nil || errRemoved != nil {
+			return fileChange{}, fmt.Errorf("invalid numstat record %q", record)
+		}
+		change.Added, change.Removed = added, removed
+	}
+
+	if change.Path == "" { // Rename, paths follow as separate records
+		var err error
+		if change.OldPath, err = next(); err != nil {
+			return fileChange{}, err
+		}
+		if change.Path, err = next(); err != nil {
+			return fileChange{}, err
+		}
+	}
+
+	return change, nil
+}
+
+// renameTracker maps paths of renamed files to their current names.
+// Commits must be tracked from newest to oldest, so renames are known before older changes are seen.
+type renameTracker map[string]string
+
+// track records rename of the file if any and returns current path of the file
+func (r renameTracker) track(oldPath, newPath string) string {
+	current := r.current(newPath)
+	if oldPath != "" && oldPath != newPath {
+		r[oldPath] = current
+	}
+
+	return current
+}
+
+func (r renameTracker) current(path string) string {
+	if current, exists := r[path]; exists {
+		return current
+	}
+	return path
+}
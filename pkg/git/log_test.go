@@ -0,0 +1,173 @@
+package git
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbvictor/ccv/pkg/complexity"
+)
+
+var (
+	hash1 = strings.Repeat("a", 40)
+	hash2 = strings.Repeat("b", 40)
+	hash3 = strings.Repeat("c", 40)
+	hash4 = strings.Repeat("d", 40)
+)
+
+// Output of git log with logFormat --numstat -z -M from newest to oldest commit:
+// hash1 modifies b.txt and renames "dir a/f 1.txt" to "dirb/f 1.txt", hash2 is a co-authored merge without changes,
+// hash3 modifies "dir a/f 1.txt" and binary file, hash4 creates files.
+var numstatLog = hash1 + "\x00Alice\x00alice@example.com\x00\x001706745600\n1\t0\tb.txt\x000\t0\t\x00dir a/f 1.txt\x00dirb/f 1.txt\x00\x00" +
+	hash2 + "\x00Bob\x00bob@example.com\x00Carol <carol@example.com>\x1fDan\x001706659200\x00" +
+	hash3 + "\x00Bob\x00bob@example.com\x00\x001704499200\n2\t1\tdir a/f 1.txt\x00-\t-\timage.png\x00\x00" +
+	hash4 + "\x00Alice\x00Alice@Example.com\x00\x001704067200\n3\t0\tdir a/f 1.txt\x001\t0\tb.txt\x00-\t-\timage.png\x00"
+
+func date(value string) time.Time {
+	parsed, _ := time.Parse(time.DateOnly, value)
+	return parsed
+}
+
+func TestParseNumstat(t *testing.T) {
+	commits := make([]*commit, 0)
+	err := parseNumstat(strings.NewReader(numstatLog), func(c *commit) error {
+		commits = append(commits, c)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []*commit{
+		{
+			Hash:   hash1,
+			Time:   date("2024-02-01"),
+			Author: author{Name: "Alice", Email: "alice@example.com"},
+			Files: []fileChange{
+				{Path: "b.txt", Added: 1},
+				{Path: "dirb/f 1.txt", OldPath: "dir a/f 1.txt"},
+			},
+		},
+		{
+			Hash:      hash2,
+			Time:      date("2024-01-31"),
+			Author:    author{Name: "Bob", Email: "bob@example.com"},
+			CoAuthors: []author{{Name: "Carol", Email: "carol@example.com"}, {Name: "Dan"}},
+		},
+		{
+			Hash:   hash3,
+			Time:   date("2024-01-06"),
+			Author: author{Name: "Bob", Email: "bob@example.com"},
+			Files: []fileChange{
+				{Path: "dir a/f 1.txt", Added: 2, Removed: 1},
+				{Path: "image.png", Binary: true},
+			},
+		},
+		{
+			Hash:   hash4,
+			Time:   date("2024-01-01"),
+			Author: author{Name: "Alice", Email: "Alice@Example.com"},
+			Files: []fileChange{
+				{Path: "dir a/f 1.txt", Added: 3},
+				{Path: "b.txt", Added: 1},
+				{Path: "image.png", Binary: true},
+			},
+		},
+	}, commits)
+}
+
+func TestParseNumstatInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+	}{
+		{name: "invalid hash", log: "not a hash\x00A\x00a@a\x00\x000\n1\t0\ta.txt\x00"},
+		{name: "invalid date", log: hash1 + "\x00A\x00a@a\x00\x00yesterday\n1\t0\ta.txt\x00"},
+		{name: "truncated header", log: hash1 + "\x00A"},
+		{name: "invalid record", log: hash1 + "\x00A\x00a@a\x00\x000\n1\t0\x00"},
+		{name: "invalid numbers", log: hash1 + "\x00A\x00a@a\x00\x000\nx\ty\ta.txt\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseNumstat(strings.NewReader(tt.log), func(*commit) error { return nil })
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestChurnAggregatorRenames(t *testing.T) {
+	aggregator := newChurnAggregator(ChurnOptions{})
+	require.NoError(t, parseNumstat(strings.NewReader(numstatLog), aggregator.add))
+
+	result := sortAndLimit(aggregator.result(), Changes, -1)
+
+	assert.Equal(t, []*complexity.ChurnChunk{
+		{File: "dirb/f 1.txt", Churn: 6, Added: 5, Removed: 1, Commits: 3},
+		{File: "b.txt", Churn: 2, Added: 2, Removed: 0, Commits: 2},
+	}, result)
+}
+
+func TestChurnAggregatorOwnership(t *testing.T) {
+	aggregator := newChurnAggregator(ChurnOptions{Authors: true})
+	require.NoError(t, parseNumstat(strings.NewReader(numstatLog), aggregator.add))
+
+	result := sortAndLimit(aggregator.result(), Changes, -1)
+	require.Len(t, result, 2)
+
+	// Alice changed 3 lines and Bob changed 3 lines, emails differ only in case
+	assert.Equal(t, &complexity.Ownership{Authors: 2, MainAuthor: "Alice", MainAuthorShare: 0.5, BusFactor: 1}, result[0].Ownership)
+	assert.Equal(t, &complexity.Ownership{Authors: 1, MainAuthor: "Alice", MainAuthorShare: 1, BusFactor: 1}, result[1].Ownership)
+}
+
+func TestChurnAggregatorWeighted(t *testing.T) {
+	aggregator := newChurnAggregator(ChurnOptions{HalfLife: 30})
+	aggregator.now = date("2024-03-02")
+	require.NoError(t, parseNumstat(strings.NewReader(numstatLog), aggregator.add))
+
+	result := sortAndLimit(aggregator.result(), Weighted, -1)
+	require.Len(t, result, 2)
+
+	// dirb/f 1.txt: 3 lines of 2024-01-06 are 56 days old and 3 lines of 2024-01-01 are 61 days old
+	assert.Equal(t, "dirb/f 1.txt", result[0].File)
+	assert.InDelta(t, 3*math.Pow(0.5, 56.0/30)+3*math.Pow(0.5, 61.0/30), result[0].WeightedChurn, 0.01)
+	// b.txt: line of 2024-02-01 is 30 days old and line of 2024-01-01 is 61 days old
+	assert.Equal(t, "b.txt", result[1].File)
+	assert.InDelta(t, 0.5+math.Pow(0.5, 61.0/30), result[1].WeightedChurn, 0.01)
+}
+
+func TestDecayWeight(t *testing.T) {
+	now := date("2024-01-31")
+
+	assert.Equal(t, 1.0, decayWeight(date("2023-01-01"), now, 0))
+	assert.Equal(t, 1.0, decayWeight(now, now, 10))
+	assert.Equal(t, 1.0, decayWeight(date("2024-02-10"), now, 10))
+	assert.InDelta(t, 0.5, decayWeight(date("2024-01-21"), now, 10), 1e-9)
+	assert.InDelta(t, 0.25, decayWeight(date("2024-01-11"), now, 10), 1e-9)
+}
+
+func TestComputeOwnership(t *testing.T) {
+	got := computeOwnership(map[string]*contribution{
+		"a": {Name: "A", Lines: 50, Commits: 1},
+		"b": {Name: "B", Lines: 30, Commits: 5},
+		"c": {Name: "C", Lines: 20, Commits: 1},
+	})
+	assert.Equal(t, &complexity.Ownership{Authors: 3, MainAuthor: "A", MainAuthorShare: 0.5, BusFactor: 1}, got)
+
+	got = computeOwnership(map[string]*contribution{
+		"a": {Name: "A", Lines: 40},
+		"b": {Name: "B", Lines: 35},
+		"c": {Name: "C", Lines: 25},
+	})
+	assert.Equal(t, &complexity.Ownership{Authors: 3, MainAuthor: "A", MainAuthorShare: 0.4, BusFactor: 2}, got)
+
+	// Renames only, commits are used as amount of changes
+	got = computeOwnership(map[string]*contribution{
+		"a": {Name: "A", Commits: 1},
+		"b": {Name: "B", Commits: 3},
+	})
+	assert.Equal(t, &complexity.Ownership{Authors: 2, MainAuthor: "B", MainAuthorShare: 0.75, BusFactor: 1}, got)
+
+	assert.Equal(t, &complexity.Ownership{}, computeOwnership(nil))
+}
@@ -0,0 +1,104 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// mailmap maps identities of commits to canonical names and emails of people.
+// Keys are lower-cased commit email, optionally followed by NUL and lower-cased commit name.
+type mailmap map[string]author
+
+// readMailmap reads .mailmap file, missing file is an empty mailmap
+func readMailmap(path string) (mailmap, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return mailmap{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening mailmap: %w", err)
+	}
+	defer f.Close()
+
+	m, err := parseMailmap(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading mailmap %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// parseMailmap parses lines in forms supported by git:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func parseMailmap(r io.Reader) (mailmap, error) {
+	m := make(mailmap)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		proper, rest, ok := cutIdentity(line)
+		if !ok {
+			continue
+		}
+
+		commitIdentity, _, ok := cutIdentity(rest)
+		if !ok {
+			// Only name is replaced for the email
+			m[mailmapKey(author{Email: proper.Email})] = author{Name: proper.Name}
+			continue
+		}
+
+		m[mailmapKey(commitIdentity)] = proper
+	}
+
+	return m, scanner.Err()
+}
+
+// cutIdentity cuts "Name <email>" from the start of s, name may be empty
+func cutIdentity(s string) (author, string, bool) {
+	start := strings.Index(s, "<")
+	end := strings.Index(s, ">")
+	if start < 0 || end < start {
+		return author{}, s, false
+	}
+
+	return author{Name: strings.TrimSpace(s[:start]), Email: strings.TrimSpace(s[start+1 : end])}, s[end+1:], true
+}
+
+func mailmapKey(a author) string {
+	key := strings.ToLower(a.Email)
+	if a.Name != "" {
+		key += "\x00" + strings.ToLower(a.Name)
+	}
+	return key
+}
+
+// resolve returns canonical identity of author, entries for name and email take precedence over entries for email
+func (m mailmap) resolve(a author) author {
+	proper, exists := m[mailmapKey(a)]
+	if !exists {
+		proper, exists = m[mailmapKey(author{Email: a.Email})]
+	}
+	if !exists {
+		return a
+	}
+
+	if proper.Name != "" {
+		a.Name = proper.Name
+	}
+	if proper.Email != "" {
+		a.Email = proper.Email
+	}
+	return a
+}
@@ -0,0 +1,38 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailmap(t *testing.T) {
+	m, err := parseMailmap(strings.NewReader(`# Canonical identities
+Alice Smith <alice@example.com>
+<bob@example.com> <bob@old.example.com>
+Carol <carol@example.com> <carol@laptop>   # laptop
+Carol <carol@example.com> carol <shared@example.com>
+not an entry
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		identity string
+		expected author
+	}{
+		{identity: "alice <Alice@Example.com>", expected: author{Name: "Alice Smith", Email: "Alice@Example.com"}},
+		{identity: "Bob <bob@old.example.com>", expected: author{Name: "Bob", Email: "bob@example.com"}},
+		{identity: "C <carol@laptop>", expected: author{Name: "Carol", Email: "carol@example.com"}},
+		{identity: "Carol <shared@example.com>", expected: author{Name: "Carol", Email: "carol@example.com"}},
+		{identity: "Dan <shared@example.com>", expected: author{Name: "Dan", Email: "shared@example.com"}},
+		{identity: "Eve <eve@example.com>", expected: author{Name: "Eve", Email: "eve@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.identity, func(t *testing.T) {
+			assert.Equal(t, tt.expected, m.resolve(parseAuthor(tt.identity)))
+		})
+	}
+}
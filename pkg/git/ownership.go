@@ -0,0 +1,67 @@
+package git
+
+import (
+	"math"
+	"sort"
+
+	"github.com/vbvictor/ccv/pkg/complexity"
+)
+
+// contribution is an amount of changes made by an author to a single file
+type contribution struct {
+	Name    string
+	Lines   uint
+	Commits uint
+}
+
+// computeOwnership estimates ownership of a file by lines changed by every author.
+// Commits are used instead of lines when no lines were changed, e.g. for renames.
+func computeOwnership(contributions map[string]*contribution) *complexity.Ownership {
+	authors := make([]*contribution, 0, len(contributions))
+	var totalLines, totalCommits uint
+	for _, c := range contributions {
+		authors = append(authors, c)
+		totalLines += c.Lines
+		totalCommits += c.Commits
+	}
+
+	if len(authors) == 0 {
+		return &complexity.Ownership{}
+	}
+
+	amount := func(c *contribution) uint { return c.Lines }
+	total := totalLines
+	if totalLines == 0 {
+		amount = func(c *contribution) uint { return c.Commits }
+		total = totalCommits
+	}
+
+	sort.Slice(authors, func(i, j int) bool {
+		if amount(authors[i]) != amount(authors[j]) {
+			return amount(authors[i]) > amount(authors[j])
+		}
+		return authors[i].Name < authors[j].Name
+	})
+
+	busFactor := uint(0)
+	var covered uint
+	for _, c := range authors {
+		busFactor++
+		covered += amount(c)
+		if 2*covered >= total {
+			break
+		}
+	}
+
+	share := 0.0
+	if total > 0 {
+		share = math.Round(float64(amount(authors[0]))/float64(total)*100) / 100
+	}
+
+	return &complexity.Ownership{
+		Authors:         uint(len(authors)),
+		MainAuthor:      authors[0].Name,
+		MainAuthorShare: share,
+		BusFactor:       busFactor,
+	}
+}
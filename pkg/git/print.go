@@ -1,10 +1,12 @@
 package git
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"github.com/vbvictor/ccv/pkg/complexity"
 	"io"
+	"slices"
 	"strings"
 )
 
@@ -17,10 +19,17 @@ var (
 	OutputFormats            = []OutputType{JSON, Tabular}
 )
 
-func printStats(results []*complexity.ChurnChunk, out io.Writer, opts ChurnOptions) error {
+// Formats of churn series, chart is rendered by plot package
+var (
+	CSV           OutputType = "csv"
+	Chart         OutputType = "chart"
+	SeriesFormats            = []OutputType{JSON, CSV, Tabular, Chart}
+)
+
+func printStats(results []*complexity.ChurnChunk, excluded []ExcludedCommit, out io.Writer, opts ChurnOptions) error {
 	switch opts.OutputFormat {
 	case JSON:
-		printJSON(results, out, opts)
+		printJSON(results, excluded, out, opts)
 	case Tabular:
 		printTable(results, out, opts)
 	default:
@@ -31,49 +40,118 @@ func printStats(results []*complexity.ChurnChunk, out io.Writer, opts ChurnOptio
 }
 
 func printTable(results []*complexity.ChurnChunk, out io.Writer, opts ChurnOptions) {
+	// Weighted churn is shown only if it is computed
+	weightedHeader := ""
+	if opts.HalfLife > 0 {
+		weightedHeader = fmt.Sprintf("%-9s ", "WEIGHTED")
+	}
+	weighted := func(chunk *complexity.ChurnChunk) string {
+		if opts.HalfLife <= 0 {
+			return ""
+		}
+		return fmt.Sprintf("%-9.2f ", chunk.WeightedChurn)
+	}
+
 	fmt.Fprintf(out, "\nTop %d most modified files (by %s):\n", opts.Top, opts.SortBy)
 	fmt.Fprintln(out, strings.Repeat("-", 100))
-	fmt.Fprintf(out, "%-8s %-8s %-8s %-8s %s\n", "CHANGES", "ADDED", "DELETED", "COMMITS", "FILEPATH")
+	if opts.Authors {
+		fmt.Fprintf(out, "%-8s %-8s %-8s %-8s %-8s %-8s %-8s %s%s\n", "CHANGES", "ADDED", "DELETED", "COMMITS", "AUTHORS", "MAIN %", "BUS", weightedHeader, "FILEPATH")
+	} else {
+		fmt.Fprintf(out, "%-8s %-8s %-8s %-8s %s%s\n", "CHANGES", "ADDED", "DELETED", "COMMITS", weightedHeader, "FILEPATH")
+	}
 	fmt.Fprintln(out, strings.Repeat("-", 100))
 
 	for _, chunk := range results {
-		fmt.Fprintf(out, "%-8d %-8d %-8d %-8d %s\n",
+		if opts.Authors && chunk.Ownership != nil {
+			fmt.Fprintf(out, "%-8d %-8d %-8d %-8d %-8d %-8.0f %-8d %s%s\n",
+				chunk.Churn,
+				chunk.Added,
+				chunk.Removed,
+				chunk.Commits,
+				chunk.Ownership.Authors,
+				chunk.Ownership.MainAuthorShare*100,
+				chunk.Ownership.BusFactor,
+				weighted(chunk),
+				chunk.File)
+			continue
+		}
+
+		fmt.Fprintf(out, "%-8d %-8d %-8d %-8d %s%s\n",
 			chunk.Churn,
 			chunk.Added,
 			chunk.Removed,
 			chunk.Commits,
+			weighted(chunk),
 			chunk.File)
 	}
 }
 
-func printJSON(results []*complexity.ChurnChunk, out io.Writer, opts ChurnOptions) {
+type jsonMetadata struct {
+	TotalFiles int    `json:"total_files"`
+	SortBy     string `json:"sort_by"`
+	FromLog    string `json:"from_log,omitempty"`
+	Filters    struct {
+		Path           string   `json:"path"`
+		Revisions      []string `json:"revisions,omitempty"`
+		Pathspecs      []string `json:"pathspecs,omitempty"`
+		FirstParent    bool     `json:"first_parent,omitempty"`
+		NoMerges       bool     `json:"no_merges,omitempty"`
+		HalfLife       float64  `json:"half_life,omitempty"`
+		ExcludePattern string   `json:"exclude_pattern"`
+		Extensions     string   `json:"extensions"`
+		IgnoreRevsFile string   `json:"ignore_revs_file,omitempty"`
+		MaxFiles       int      `json:"max_files_per_commit,omitempty"`
+		NoWhitespace   bool     `json:"ignore_whitespace,omitempty"`
+		Author         string   `json:"author,omitempty"`
+		ExcludeAuthor  string   `json:"exclude_author,omitempty"`
+		DateRange      struct {
+			Since string `json:"since"`
+			Until string `json:"until"`
+		} `json:"date_range"`
+	} `json:"filters"`
+	// Commits which changes are not counted and reasons of their exclusion
+	ExcludedCommits []ExcludedCommit `json:"excluded_commits,omitempty"`
+}
+
+func newJSONMetadata(totalFiles int, opts ChurnOptions) jsonMetadata {
+	var metadata jsonMetadata
+
+	metadata.TotalFiles = totalFiles
+	metadata.SortBy = opts.SortBy
+	metadata.FromLog = opts.FromLog
+	metadata.Filters.Path = opts.Path
+	metadata.Filters.Revisions = opts.Revisions
+	metadata.Filters.Pathspecs = opts.Pathspecs
+	metadata.Filters.FirstParent = opts.FirstParent
+	metadata.Filters.NoMerges = opts.NoMerges
+	metadata.Filters.HalfLife = opts.HalfLife
+	metadata.Filters.ExcludePattern = opts.ExcludePath
+	metadata.Filters.Extensions = opts.Extensions
+	metadata.Filters.IgnoreRevsFile = opts.IgnoreRevsFile
+	metadata.Filters.MaxFiles = opts.MaxFilesPerCommit
+	metadata.Filters.NoWhitespace = opts.IgnoreWhitespace
+	metadata.Filters.Author = opts.Author
+	metadata.Filters.ExcludeAuthor = opts.ExcludeAuthor
+	metadata.Filters.DateRange.Since = opts.Since.String()
+	metadata.Filters.DateRange.Until = opts.Until.String()
+
+	return metadata
+}
+
+func printJSON(results []*complexity.ChurnChunk, excluded []ExcludedCommit, out io.Writer, opts ChurnOptions) {
 	output := struct {
-		Metadata struct {
-			TotalFiles int    `json:"total_files"`
-			SortBy     string `json:"sort_by"`
-			Filters    struct {
-				Path           string `json:"path"`
-				ExcludePattern string `json:"exclude_pattern"`
-				Extensions     string `json:"extensions"`
-				DateRange      struct {
-					Since string `json:"since"`
-					Until string `json:"until"`
-				} `json:"date_range"`
-			} `json:"filters"`
-		} `json:"metadata"`
-		Files []*complexity.ChurnChunk `json:"files"`
+		Metadata jsonMetadata             `json:"metadata"`
+		Files    []*complexity.ChurnChunk `json:"files"`
 	}{
-		Files: results,
+		Metadata: newJSONMetadata(len(results), opts),
+		Files:    results,
 	}
+	output.Metadata.ExcludedCommits = excluded
 
-	output.Metadata.TotalFiles = len(results)
-	output.Metadata.SortBy = opts.SortBy
-	output.Metadata.Filters.Path = opts.Path
-	output.Metadata.Filters.ExcludePattern = opts.ExcludePath
-	output.Metadata.Filters.Extensions = opts.Extensions
-	output.Metadata.Filters.DateRange.Since = opts.Since.String()
-	output.Metadata.Filters.DateRange.Until = opts.Until.String()
+	writeJSON(output, out)
+}
 
+func writeJSON(output any, out io.Writer) {
 	json, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		fmt.Fprintf(out, "Error creating JSON output: %v\n", err)
@@ -81,3 +159,191 @@ func printJSON(results []*complexity.ChurnChunk, out io.Writer, opts ChurnOption
 	}
 	fmt.Fprintln(out, string(json))
 }
+
+func printSeries(series *ChurnSeries, out io.Writer, opts ChurnOptions) error {
+	switch opts.OutputFormat {
+	case JSON:
+		printSeriesJSON(series, out, opts)
+	case CSV:
+		return printSeriesCSV(series, out)
+	case Tabular:
+		printSeriesTable(series, out, opts)
+	default:
+		return fmt.Errorf("Invalid output format. Use one of the following: %v", SeriesFormats)
+	}
+
+	return nil
+}
+
+func printSeriesTable(series *ChurnSeries, out io.Writer, opts ChurnOptions) {
+	fmt.Fprintf(out, "\nTop %d most modified files (by %s) per %s:\n", opts.Top, opts.SortBy, series.Bucket)
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+	fmt.Fprintf(out, "%-10s %-8s %-8s %-8s %-8s %s\n", "PERIOD", "CHANGES", "ADDED", "DELETED", "COMMITS", "FILEPATH")
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+
+	for _, file := range series.Files {
+		for _, point := range file.Points {
+			fmt.Fprintf(out, "%-10s %-8d %-8d %-8d %-8d %s\n",
+				point.Period,
+				point.Churn,
+				point.Added,
+				point.Removed,
+				point.Commits,
+				file.File)
+		}
+	}
+}
+
+func printSeriesJSON(series *ChurnSeries, out io.Writer, opts ChurnOptions) {
+	output := struct {
+		Metadata struct {
+			jsonMetadata
+			Bucket  string   `json:"bucket"`
+			Periods []string `json:"periods"`
+		} `json:"metadata"`
+		Files []*FileSeries `json:"files"`
+	}{
+		Files: series.Files,
+	}
+
+	output.Metadata.jsonMetadata = newJSONMetadata(len(series.Files), opts)
+	output.Metadata.Bucket = series.Bucket
+	output.Metadata.Periods = series.Periods
+
+	writeJSON(output, out)
+}
+
+// printSeriesCSV writes a row per file and period
+func printSeriesCSV(series *ChurnSeries, out io.Writer) error {
+	writer := csv.NewWriter(out)
+
+	if err := writer.Write([]string{"Period", "Start", "Changes", "Additions", "Deletions", "Commits", "FilePath"}); err != nil {
+		return fmt.Errorf("error writing CSV header: %w", err)
+	}
+
+	for _, file := range series.Files {
+		for _, point := range file.Points {
+			record := []string{
+				point.Period,
+				point.Start,
+				fmt.Sprint(point.Churn),
+				fmt.Sprint(point.Added),
+				fmt.Sprint(point.Removed),
+				fmt.Sprint(point.Commits),
+				file.File,
+			}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("error writing CSV record: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func printFunctionStats(results []*complexity.FunctionChurn, excluded []ExcludedCommit, out io.Writer, opts ChurnOptions) error {
+	switch opts.OutputFormat {
+	case JSON:
+		printFunctionJSON(results, excluded, out, opts)
+	case Tabular:
+		printFunctionTable(results, out, opts)
+	default:
+		return fmt.Errorf("Invalid output format. Use one of the following: %v", OutputFormats)
+	}
+
+	return nil
+}
+
+func printFunctionTable(results []*complexity.FunctionChurn, out io.Writer, opts ChurnOptions) {
+	fmt.Fprintf(out, "\nTop %d most modified functions (by %s):\n", opts.Top, opts.SortBy)
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+	fmt.Fprintf(out, "%-8s %-8s %-8s %-8s %-30s %s\n", "CHANGES", "ADDED", "DELETED", "COMMITS", "FUNCTION", "FILEPATH")
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+
+	for _, fn := range results {
+		fmt.Fprintf(out, "%-8d %-8d %-8d %-8d %-30s %s:%d\n",
+			fn.Churn,
+			fn.Added,
+			fn.Removed,
+			fn.Commits,
+			strings.Join(append(slices.Clone(fn.Package), fn.Name), "::"),
+			fn.File,
+			fn.Line)
+	}
+}
+
+func printFunctionJSON(results []*complexity.FunctionChurn, excluded []ExcludedCommit, out io.Writer, opts ChurnOptions) {
+	files := make(map[string]bool)
+	for _, fn := range results {
+		files[fn.File] = true
+	}
+
+	output := struct {
+		Metadata  jsonMetadata                `json:"metadata"`
+		Functions []*complexity.FunctionChurn `json:"functions"`
+	}{
+		Metadata:  newJSONMetadata(len(files), opts),
+		Functions: results,
+	}
+	output.Metadata.ExcludedCommits = excluded
+
+	writeJSON(output, out)
+}
+
+func printCoupling(results []*Coupling, out io.Writer, opts ChurnOptions, copts CouplingOptions) error {
+	switch opts.OutputFormat {
+	case JSON:
+		printCouplingJSON(results, out, opts, copts)
+	case Tabular:
+		printCouplingTable(results, out, opts)
+	default:
+		return fmt.Errorf("Invalid output format. Use one of the following: %v", OutputFormats)
+	}
+
+	return nil
+}
+
+func printCouplingTable(results []*Coupling, out io.Writer, opts ChurnOptions) {
+	fmt.Fprintf(out, "\nTop %d most coupled files (shared commits / commits of either file):\n", opts.Top)
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+	fmt.Fprintf(out, "%-8s %-8s %-8s %-8s %s\n", "DEGREE", "SHARED", "REVS", "REVS", "FILES")
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+
+	for _, coupling := range results {
+		fmt.Fprintf(out, "%-8.2f %-8d %-8d %-8d %s <-> %s\n",
+			coupling.Degree,
+			coupling.SharedCommits,
+			coupling.Revisions,
+			coupling.CoupledRevisions,
+			coupling.File,
+			coupling.Coupled)
+	}
+}
+
+func printCouplingJSON(results []*Coupling, out io.Writer, opts ChurnOptions, copts CouplingOptions) {
+	files := make(map[string]bool)
+	for _, coupling := range results {
+		files[coupling.File] = true
+		files[coupling.Coupled] = true
+	}
+
+	output := struct {
+		Metadata struct {
+			jsonMetadata
+			MinSharedCommits uint `json:"min_shared_commits"`
+			MinRevisions     uint `json:"min_revisions"`
+			MaxChangesetSize int  `json:"max_changeset_size"`
+		} `json:"metadata"`
+		Couplings []*Coupling `json:"couplings"`
+	}{
+		Couplings: results,
+	}
+
+	output.Metadata.jsonMetadata = newJSONMetadata(len(files), opts)
+	output.Metadata.MinSharedCommits = copts.MinSharedCommits
+	output.Metadata.MinRevisions = copts.MinRevisions
+	output.Metadata.MaxChangesetSize = copts.MaxChangesetSize
+
+	writeJSON(output, out)
+}
@@ -46,6 +46,31 @@ func TestPrintTable(t *testing.T) {
 	assert.Equal(t, expected, buf.String())
 }
 
+func TestPrintTableAuthors(t *testing.T) {
+	var buf bytes.Buffer
+
+	results := []*complexity.ChurnChunk{
+		{
+			File:      "main.go",
+			Churn:     20,
+			Added:     15,
+			Removed:   5,
+			Commits:   3,
+			Ownership: &complexity.Ownership{Authors: 2, MainAuthor: "Alice", MainAuthorShare: 0.75, BusFactor: 1},
+		},
+	}
+
+	printTable(results, &buf, ChurnOptions{Top: 1, SortBy: "churn", Authors: true})
+
+	expected := "\nTop 1 most modified files (by churn):\n" +
+		"----------------------------------------------------------------------------------------------------\n" +
+		"CHANGES  ADDED    DELETED  COMMITS  AUTHORS  MAIN %   BUS      FILEPATH\n" +
+		"----------------------------------------------------------------------------------------------------\n" +
+		"20       15       5        3        2        75       1        main.go\n"
+
+	assert.Equal(t, expected, buf.String())
+}
+
 func TestPrintJSON(t *testing.T) {
 	var buf bytes.Buffer
 
@@ -72,7 +97,7 @@ func TestPrintJSON(t *testing.T) {
 		Until:       Date{until},
 	}
 
-	printJSON(results, &buf, opts)
+	printJSON(results, nil, &buf, opts)
 
 	expected := `{
   "metadata": {
@@ -1,14 +1,16 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"github.com/vbvictor/ccv/pkg/complexity"
+	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +26,7 @@ var (
 	Additions SortType = "additions"
 	Deletions SortType = "deletions"
 	Commits   SortType = "commits"
+	Weighted  SortType = "weighted"
 )
 
 var _ pflag.Value = (*Date)(nil)
@@ -59,6 +62,37 @@ type ChurnOptions struct {
 	Since        Date
 	Until        Date
 	OutputFormat OutputType
+	// Collect authors of commits and compute ownership of files
+	Authors bool
+	// Revisions or revision ranges which history is analyzed, HEAD if empty
+	Revisions []string
+	// Follow only the first parent of merge commits, merges are compared with their first parent
+	FirstParent bool
+	NoMerges    bool
+	// Pathspecs limiting analyzed files, relative to Path
+	Pathspecs []string
+	// Split churn of files into series of periods, disabled if empty
+	Bucket BucketType
+	// Number of days after which weight of commit changes halves, weighted churn is not computed if 0
+	HalfLife float64
+	// Keep numstat of commits on disk, so later runs read only new commits
+	Cache bool
+	// Directory of commit cache, ccv directory inside .git if empty
+	CacheDir string
+	// Writer of progress reports, nothing is reported if nil
+	Progress io.Writer
+	// File with output of git log --pretty=format:%H --numstat read instead of running git, "-" is stdin
+	FromLog string
+	// File with hashes of commits to skip in format of .git-blame-ignore-revs
+	IgnoreRevsFile string
+	// Skip commits that change more files, 0 disables the limit
+	MaxFilesPerCommit int
+	// Ignore whitespace when counting changed lines
+	IgnoreWhitespace bool
+	// Analyze only commits which author or co-author matches regex, authors are normalized by .mailmap
+	Author string
+	// Skip commits which author matches regex, matching co-authors are not counted
+	ExcludeAuthor string
 }
 
 var ChurnOpts = ChurnOptions{
@@ -71,97 +105,300 @@ var ChurnOpts = ChurnOptions{
 	Since:        Date{},
 	Until:        Date{},
 	OutputFormat: Tabular,
+	Authors:      false,
+	Revisions:    []string{},
+	FirstParent:  false,
+	NoMerges:     false,
+	Pathspecs:    []string{},
+	Bucket:       "",
+	HalfLife:     0,
+	Cache:        false,
+	CacheDir:     "",
+	Progress:     nil,
+	FromLog:      "",
+	// Bulk-change commits are not excluded by default
+	IgnoreRevsFile:    "",
+	MaxFilesPerCommit: 0,
+	IgnoreWhitespace:  false,
+	Author:            "",
+	ExcludeAuthor:     "",
 }
 
-func PrintRepoStats(repoPath string) error {
-	churns, err := MostChurnFiles(repoPath)
+func PrintRepoStats(ctx context.Context, repoPath string) error {
+	churns, excluded, err := readChurn(ctx, repoPath, ChurnOpts)
 	if err != nil {
 		return fmt.Errorf("error getting churn metrics: %w", err)
 	}
 
-	return printStats(churns, os.Stdout, ChurnOpts)
+	return printStats(churns, excluded, os.Stdout, ChurnOpts)
 }
 
-func MostChurnFiles(repoPath string) ([]*complexity.ChurnChunk, error) {
-	return ReadChurn(repoPath, ChurnOpts)
+func MostChurnFiles(ctx context.Context, repoPath string) ([]*complexity.ChurnChunk, error) {
+	return ReadChurn(ctx, repoPath, ChurnOpts)
 }
 
-func ReadChurn(repoPath string, opts ChurnOptions) ([]*complexity.ChurnChunk, error) {
-	cmd := []string{"git", "log", "--pretty=format:%H", "--numstat"}
+func ReadChurn(ctx context.Context, repoPath string, opts ChurnOptions) ([]*complexity.ChurnChunk, error) {
+	churns, _, err := readChurn(ctx, repoPath, opts)
+	return churns, err
+}
 
-	if opts.CommitCount > 0 {
-		cmd = append(cmd, fmt.Sprintf("-n%d", opts.CommitCount))
+// readChurn reads churn of files and commits excluded from it
+func readChurn(ctx context.Context, repoPath string, opts ChurnOptions) ([]*complexity.ChurnChunk, []ExcludedCommit, error) {
+	aggregator := newChurnAggregator(opts)
+	excluded, err := readCommits(ctx, repoPath, opts, aggregator.renames, aggregator.add)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if !opts.Since.IsZero() {
-		cmd = append(cmd, fmt.Sprintf("--since=%s", opts.Since.String()))
+	return sortAndLimit(aggregator.result(), opts.SortBy, opts.Top), excluded, nil
+}
+
+// readCommits calls fn for every commit from newest to oldest and returns commits excluded by options.
+// Renames of excluded commits are recorded to renames of aggregator that fn adds commits to.
+func readCommits(ctx context.Context, repoPath string, opts ChurnOptions, renames renameTracker, fn func(*commit) error) ([]ExcludedCommit, error) {
+	filter, err := newCommitFilter(repoPath, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	if !opts.Until.IsZero() {
-		cmd = append(cmd, fmt.Sprintf("--until=%s", opts.Until.String()))
+	progress := newProgress(opts.Progress)
+	defer progress.done()
+
+	if err := streamCommits(ctx, repoPath, opts, progress.wrap(filter.wrap(fn, renames))); err != nil {
+		return nil, err
 	}
 
-	cmd = append(cmd, "--", repoPath)
+	return filter.excluded, nil
+}
+
+// streamCommits reads commits with numstat from git log, its saved dump or cache
+func streamCommits(ctx context.Context, repoPath string, opts ChurnOptions, fn func(*commit) error) error {
+	if opts.FromLog != "" {
+		return readLogDump(ctx, opts, fn)
+	}
 
-	gitCmd := exec.Command(cmd[0], cmd[1:]...)
-	gitCmd.Dir = repoPath
-	output, err := gitCmd.Output()
+	if opts.Cache || opts.CacheDir != "" {
+		return readCachedCommits(ctx, repoPath, opts, fn)
+	}
+
+	gitCmd, err := logCommand(ctx, repoPath, opts, "log", prettyFormat(), "--numstat", "-z", "-M")
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute git command: %v", err)
+		return err
+	}
+
+	return streamCommand(ctx, gitCmd, func(r io.Reader) error {
+		return parseNumstat(r, fn)
+	})
+}
+
+// logCommand creates git command with given arguments followed by filters of options.
+// Command runs in Path subdirectory of repository, so pathspecs are relative to it,
+// while paths of files in output stay relative to repository root.
+func logCommand(ctx context.Context, repoPath string, opts ChurnOptions, args ...string) (*exec.Cmd, error) {
+	args = append(args, logFilterArgs(opts)...)
+	args = append(args, diffArgs(opts)...)
+
+	return pathspecCommand(ctx, repoPath, opts, args...)
+}
+
+// pathspecCommand creates git command running in Path subdirectory with arguments followed by pathspecs
+func pathspecCommand(ctx context.Context, repoPath string, opts ChurnOptions, args ...string) (*exec.Cmd, error) {
+	for _, rev := range opts.Revisions {
+		if rev == "" || strings.HasPrefix(rev, "-") {
+			return nil, fmt.Errorf("invalid revision %q", rev)
+		}
+	}
+
+	dir := repoPath
+	if opts.Path != "" {
+		dir = opts.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(repoPath, dir)
+		}
+
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("path %q is not a directory of repository", opts.Path)
+		}
 	}
 
-	fileStats := make(map[string]*complexity.ChurnChunk)
-	lines := strings.Split(string(output), "\n")
+	args = append(args, "--")
+	if len(opts.Pathspecs) > 0 {
+		args = append(args, opts.Pathspecs...)
+	} else {
+		args = append(args, ".")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	return cmd, nil
+}
 
-	currentCommit := ""
+// HeadCommit returns hash of commit checked out in repository
+func HeadCommit(ctx context.Context, repoPath string) (string, error) {
+	return gitOutput(ctx, repoPath, "rev-parse", "HEAD")
+}
+
+// churnAggregator sums changes of commits ordered from newest to oldest.
+// Changes made under old names of renamed files are attributed to their current names.
+type churnAggregator struct {
+	opts      ChurnOptions
+	fileStats map[string]*complexity.ChurnChunk
+	renames   renameTracker
+	// Changes of every author per file, collected only if authors are requested
+	contributions map[string]map[string]*contribution
+	// Changes per file and start of period, collected only if bucket is set
+	periods                 map[string]map[time.Time]*ChurnPoint
+	firstPeriod, lastPeriod time.Time
+	// Time from which age of commits is counted
+	now time.Time
+}
+
+func newChurnAggregator(opts ChurnOptions) *churnAggregator {
+	return &churnAggregator{
+		opts:          opts,
+		fileStats:     make(map[string]*complexity.ChurnChunk),
+		renames:       make(renameTracker),
+		contributions: make(map[string]map[string]*contribution),
+		periods:       make(map[string]map[time.Time]*ChurnPoint),
+		now:           time.Now(),
+	}
+}
+
+func (a *churnAggregator) add(c *commit) error {
 	modifiedInCommit := make(map[string]bool)
+	weight := decayWeight(c.Time, a.now, a.opts.HalfLife)
+
+	for _, change := range c.Files {
+		filepath := a.renames.track(change.OldPath, change.Path)
 
-	for _, line := range lines {
-		if len(line) == 0 {
+		if change.Binary || shouldSkipFile(filepath, a.opts.ExcludePath, a.opts.Extensions) {
 			continue
 		}
 
-		if len(line) == 40 { // Commit hash
-			if currentCommit != "" && len(modifiedInCommit) > 0 {
-				for filepath := range modifiedInCommit {
-					if shouldSkipFile(filepath, opts.ExcludePath, opts.Extensions) {
-						continue
-					}
-					fileStats[filepath].Commits++
-				}
-			}
-			currentCommit = line
-			modifiedInCommit = make(map[string]bool)
-		} else {
-			parts := strings.Fields(line)
-			if len(parts) == 3 && isNumeric(parts[0]) && isNumeric(parts[1]) {
-				additions, _ := strconv.Atoi(parts[0])
-				deletions, _ := strconv.Atoi(parts[1])
-				filepath := parts[2]
-
-				if shouldSkipFile(filepath, opts.ExcludePath, opts.Extensions) {
-					continue
-				}
-
-				if _, exists := fileStats[filepath]; !exists {
-					fileStats[filepath] = &complexity.ChurnChunk{File: filepath}
-				}
-
-				fileStats[filepath].Added += uint(additions)
-				fileStats[filepath].Removed += uint(deletions)
-				fileStats[filepath].Churn += uint(additions + deletions)
-				modifiedInCommit[filepath] = true
-			}
+		if _, exists := a.fileStats[filepath]; !exists {
+			a.fileStats[filepath] = &complexity.ChurnChunk{File: filepath}
+		}
+
+		a.fileStats[filepath].Added += uint(change.Added)
+		a.fileStats[filepath].Removed += uint(change.Removed)
+		a.fileStats[filepath].Churn += uint(change.Added + change.Removed)
+		if a.opts.HalfLife > 0 {
+			a.fileStats[filepath].WeightedChurn += weight * float64(change.Added+change.Removed)
+		}
+
+		if a.opts.Bucket != "" {
+			a.addToPeriod(c, filepath, change, !modifiedInCommit[filepath])
+		}
+		modifiedInCommit[filepath] = true
+	}
+
+	for filepath := range modifiedInCommit {
+		a.fileStats[filepath].Commits++
+	}
+
+	if a.opts.Authors {
+		a.addContributions(c)
+	}
+
+	return nil
+}
+
+func (a *churnAggregator) addContributions(c *commit) {
+	for _, person := range append([]author{c.Author}, c.CoAuthors...) {
+		a.addContribution(c, person)
+	}
+}
+
+// addContribution credits person with changes of commit, co-authors are credited the same as author
+func (a *churnAggregator) addContribution(c *commit, person author) {
+	key := person.key()
+
+	for _, change := range c.Files {
+		filepath := a.renames.current(change.Path)
+		if _, exists := a.fileStats[filepath]; !exists || change.Binary {
+			continue
+		}
+
+		if a.contributions[filepath] == nil {
+			a.contributions[filepath] = make(map[string]*contribution)
 		}
+
+		contrib, exists := a.contributions[filepath][key]
+		if !exists {
+			contrib = &contribution{Name: person.Name}
+			a.contributions[filepath][key] = contrib
+		}
+
+		contrib.Lines += uint(change.Added + change.Removed)
+		contrib.Commits++
+	}
+}
+
+func (a *churnAggregator) result() []*complexity.ChurnChunk {
+	for filepath, stats := range a.fileStats {
+		stats.WeightedChurn = math.Round(stats.WeightedChurn*100) / 100
+		if a.opts.Authors {
+			stats.Ownership = computeOwnership(a.contributions[filepath])
+		}
+	}
+
+	return maps.Values(a.fileStats)
+}
+
+// decayWeight returns weight of changes made at t, weight halves every halfLife days before now.
+// Changes are not weighted if halfLife is not positive.
+func decayWeight(t, now time.Time, halfLife float64) float64 {
+	if halfLife <= 0 {
+		return 1
+	}
+
+	age := max(now.Sub(t).Hours()/24, 0)
+
+	return math.Pow(0.5, age/halfLife)
+}
+
+// diffArgs returns arguments that change how commits are compared with their parents
+func diffArgs(opts ChurnOptions) []string {
+	args := []string{}
+	if opts.FirstParent {
+		args = append(args, "--diff-merges=first-parent")
 	}
 
-	result := maps.Values(fileStats)
-	return sortAndLimit(result, opts.SortBy, opts.Top), nil
+	if opts.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+
+	return args
 }
 
-func isNumeric(s string) bool {
-	_, err := strconv.Atoi(s)
-	return err == nil
+// logFilterArgs returns git log arguments that select commits to analyze
+func logFilterArgs(opts ChurnOptions) []string {
+	args := make([]string, 0)
+
+	if opts.CommitCount > 0 {
+		args = append(args, fmt.Sprintf("-n%d", opts.CommitCount))
+	}
+
+	if !opts.Since.IsZero() {
+		args = append(args, fmt.Sprintf("--since=%s", opts.Since.String()))
+	}
+
+	if !opts.Until.IsZero() {
+		args = append(args, fmt.Sprintf("--until=%s", opts.Until.String()))
+	}
+
+	if opts.FirstParent {
+		args = append(args, "--first-parent")
+	}
+
+	if opts.NoMerges {
+		args = append(args, "--no-merges")
+	}
+
+	args = append(args, opts.Revisions...)
+
+	return args
 }
 
 func shouldSkipFile(file, excludePath, extensions string) bool {
@@ -190,16 +427,27 @@ func shouldSkipFile(file, excludePath, extensions string) bool {
 }
 
 func sortAndLimit(result []*complexity.ChurnChunk, sortBy SortType, limit int) []*complexity.ChurnChunk {
+	return sortAndLimitBy(result, func(c *complexity.ChurnChunk) *complexity.ChurnChunk { return c }, sortBy, limit)
+}
+
+func sortAndLimitFunctions(result []*complexity.FunctionChurn, sortBy SortType, limit int) []*complexity.FunctionChurn {
+	return sortAndLimitBy(result, func(c *complexity.FunctionChurn) *complexity.ChurnChunk { return &c.ChurnChunk }, sortBy, limit)
+}
+
+// sortAndLimitBy sorts any results containing churn chunk in descending order
+func sortAndLimitBy[T any](result []T, chunk func(T) *complexity.ChurnChunk, sortBy SortType, limit int) []T {
 	less := func() func(i, j int) bool {
 		switch sortBy {
 		case Changes:
-			return func(i, j int) bool { return result[i].Churn > result[j].Churn }
+			return func(i, j int) bool { return chunk(result[i]).Churn > chunk(result[j]).Churn }
 		case Additions:
-			return func(i, j int) bool { return result[i].Added > result[j].Added }
+			return func(i, j int) bool { return chunk(result[i]).Added > chunk(result[j]).Added }
 		case Deletions:
-			return func(i, j int) bool { return result[i].Removed > result[j].Removed }
+			return func(i, j int) bool { return chunk(result[i]).Removed > chunk(result[j]).Removed }
 		case Commits:
-			return func(i, j int) bool { return result[i].Commits > result[j].Commits }
+			return func(i, j int) bool { return chunk(result[i]).Commits > chunk(result[j]).Commits }
+		case Weighted:
+			return func(i, j int) bool { return chunk(result[i]).WeightedChurn > chunk(result[j]).WeightedChurn }
 		default:
 			return nil
 		}
@@ -1,9 +1,11 @@
 package git
 
 import (
+	"context"
 	"github.com/vbvictor/ccv/pkg/complexity"
 	"os/exec"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -204,7 +206,7 @@ func TestMostModifiedFiles(t *testing.T) {
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := ReadChurn(tmpDir, ChurnOptions{SortBy: tt.sortBy, Top: tt.top})
+			results, err := ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: tt.sortBy, Top: tt.top})
 			assert.NoError(t, err)
 			assert.Len(t, results, len(tt.expected))
 
@@ -225,3 +227,44 @@ func Unbundle(t *testing.T, src, dst string) {
 	cmd := exec.Command("git", "clone", src, dst)
 	require.NoError(t, cmd.Run())
 }
+
+func TestReadChurnRevisionsAndPathspecs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	Unbundle(t, "../../test/bundles/churn-test.bundle", tmpDir)
+
+	results, err := ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: Changes, Top: -1, Revisions: []string{"HEAD~3..HEAD"}})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, complexity.ChurnChunk{File: "main.cpp", Added: 3, Removed: 8, Churn: 11, Commits: 2}, *results[0])
+	assert.Equal(t, complexity.ChurnChunk{File: "main.go", Added: 7, Removed: 0, Churn: 7, Commits: 1}, *results[1])
+
+	results, err = ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: Changes, Top: -1, Path: ".", Pathspecs: []string{"*.cpp", "*.md"}})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "main.cpp", results[0].File)
+	assert.Equal(t, "Readme.md", results[1].File)
+
+	_, err = ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: Changes, Path: "missing"})
+	assert.Error(t, err)
+
+	_, err = ReadChurn(context.Background(), tmpDir, ChurnOptions{SortBy: Changes, Revisions: []string{"--all"}})
+	assert.Error(t, err)
+}
+
+func TestLogFilterArgs(t *testing.T) {
+	since, _ := time.Parse(time.DateOnly, "2024-01-01")
+
+	opts := ChurnOptions{
+		CommitCount: 5,
+		Since:       Date{since},
+		FirstParent: true,
+		NoMerges:    true,
+		Revisions:   []string{"v1.2..v1.3", "main..feature"},
+	}
+
+	assert.Equal(t, []string{
+		"-n5", "--since=2024-01-01", "--first-parent", "--no-merges", "v1.2..v1.3", "main..feature",
+	}, logFilterArgs(opts))
+	assert.Equal(t, []string{"--diff-merges=first-parent"}, diffArgs(opts))
+}
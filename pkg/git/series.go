@@ -0,0 +1,204 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/vbvictor/ccv/pkg/complexity"
+)
+
+// BucketType is a length of period that churn series are split by
+type BucketType = string
+
+var (
+	Week    BucketType = "week"
+	Month   BucketType = "month"
+	Quarter BucketType = "quarter"
+	Buckets            = []BucketType{Week, Month, Quarter}
+)
+
+// ChurnPoint is a churn of a file during a single period
+type ChurnPoint struct {
+	Period  string `json:"period"`
+	Start   string `json:"start"`
+	Churn   uint   `json:"changes"`
+	Added   uint   `json:"additions"`
+	Removed uint   `json:"deletions"`
+	Commits uint   `json:"commits"`
+}
+
+// FileSeries is a total churn of a file and its churn in every period
+type FileSeries struct {
+	complexity.ChurnChunk
+	Points []ChurnPoint `json:"series"`
+}
+
+// ChurnSeries is a churn of files split by periods.
+// All files share the same periods from the oldest to the newest commit, periods without changes are zero.
+type ChurnSeries struct {
+	Bucket  BucketType
+	Periods []string
+	Files   []*FileSeries
+}
+
+// Values returns metric of every period, metric is one of sort types except weighted
+func (s *FileSeries) Values(metric SortType) []uint {
+	values := make([]uint, 0, len(s.Points))
+	for _, point := range s.Points {
+		switch metric {
+		case Additions:
+			values = append(values, point.Added)
+		case Deletions:
+			values = append(values, point.Removed)
+		case Commits:
+			values = append(values, point.Commits)
+		default:
+			values = append(values, point.Churn)
+		}
+	}
+
+	return values
+}
+
+func ValidateBucket(bucket BucketType) error {
+	if !slices.Contains(Buckets, bucket) {
+		return fmt.Errorf("invalid bucket %q. Use one of the following: %v", bucket, Buckets)
+	}
+
+	return nil
+}
+
+func PrintRepoSeries(ctx context.Context, repoPath string) error {
+	series, err := ReadChurnSeries(ctx, repoPath, ChurnOpts)
+	if err != nil {
+		return fmt.Errorf("error getting churn series: %w", err)
+	}
+
+	return printSeries(series, os.Stdout, ChurnOpts)
+}
+
+// ReadChurnSeries computes churn of files per period of opts.Bucket.
+// Files are sorted and limited by their total churn.
+func ReadChurnSeries(ctx context.Context, repoPath string, opts ChurnOptions) (*ChurnSeries, error) {
+	if err := ValidateBucket(opts.Bucket); err != nil {
+		return nil, err
+	}
+
+	if opts.SortBy == Weighted {
+		return nil, fmt.Errorf("--sort %s can not be used with --bucket, weighted churn is not split by periods", Weighted)
+	}
+
+	aggregator := newChurnAggregator(opts)
+	if _, err := readCommits(ctx, repoPath, opts, aggregator.renames, aggregator.add); err != nil {
+		return nil, err
+	}
+
+	return aggregator.series(sortAndLimit(aggregator.result(), opts.SortBy, opts.Top)), nil
+}
+
+// addToPeriod adds change of file to period of commit, file is counted once per commit
+func (a *churnAggregator) addToPeriod(c *commit, filepath string, change fileChange, first bool) {
+	start := periodStart(c.Time, a.opts.Bucket)
+
+	if a.periods[filepath] == nil {
+		a.periods[filepath] = make(map[time.Time]*ChurnPoint)
+	}
+
+	point, exists := a.periods[filepath][start]
+	if !exists {
+		point = &ChurnPoint{}
+		a.periods[filepath][start] = point
+	}
+
+	point.Added += uint(change.Added)
+	point.Removed += uint(change.Removed)
+	point.Churn += uint(change.Added + change.Removed)
+	if first {
+		point.Commits++
+	}
+
+	if a.firstPeriod.IsZero() || start.Before(a.firstPeriod) {
+		a.firstPeriod = start
+	}
+	if start.After(a.lastPeriod) {
+		a.lastPeriod = start
+	}
+}
+
+// series fills periods of files from the first to the last period of all commits
+func (a *churnAggregator) series(files []*complexity.ChurnChunk) *ChurnSeries {
+	result := &ChurnSeries{
+		Bucket:  a.opts.Bucket,
+		Periods: make([]string, 0),
+		Files:   make([]*FileSeries, 0, len(files)),
+	}
+
+	starts := make([]time.Time, 0)
+	if !a.firstPeriod.IsZero() {
+		for start := a.firstPeriod; !start.After(a.lastPeriod); start = nextPeriod(start, a.opts.Bucket) {
+			starts = append(starts, start)
+			result.Periods = append(result.Periods, periodName(start, a.opts.Bucket))
+		}
+	}
+
+	for _, file := range files {
+		fileSeries := &FileSeries{ChurnChunk: *file, Points: make([]ChurnPoint, 0, len(starts))}
+
+		for i, start := range starts {
+			point := ChurnPoint{}
+			if p, exists := a.periods[file.File][start]; exists {
+				point = *p
+			}
+			point.Period = result.Periods[i]
+			point.Start = start.Format(time.DateOnly)
+
+			fileSeries.Points = append(fileSeries.Points, point)
+		}
+
+		result.Files = append(result.Files, fileSeries)
+	}
+
+	return result
+}
+
+// periodStart returns the first day of period that contains t, weeks start on Monday
+func periodStart(t time.Time, bucket BucketType) time.Time {
+	year, month, day := t.UTC().Date()
+
+	switch bucket {
+	case Week:
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	case Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func nextPeriod(start time.Time, bucket BucketType) time.Time {
+	switch bucket {
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 3, 0)
+	}
+}
+
+// periodName returns label of period: ISO week 2024-W05, month 2024-02 or quarter 2024-Q1
+func periodName(start time.Time, bucket BucketType) string {
+	switch bucket {
+	case Week:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case Month:
+		return start.Format("2006-01")
+	default:
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	}
+}
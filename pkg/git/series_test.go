@@ -0,0 +1,86 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChurnAggregatorSeries(t *testing.T) {
+	aggregator := newChurnAggregator(ChurnOptions{Bucket: Month})
+	require.NoError(t, parseNumstat(strings.NewReader(numstatLog), aggregator.add))
+
+	series := aggregator.series(sortAndLimit(aggregator.result(), Changes, -1))
+
+	assert.Equal(t, []string{"2024-01", "2024-02"}, series.Periods)
+	require.Len(t, series.Files, 2)
+
+	assert.Equal(t, "dirb/f 1.txt", series.Files[0].File)
+	assert.Equal(t, []ChurnPoint{
+		{Period: "2024-01", Start: "2024-01-01", Churn: 6, Added: 5, Removed: 1, Commits: 2},
+		{Period: "2024-02", Start: "2024-02-01", Churn: 0, Added: 0, Removed: 0, Commits: 1},
+	}, series.Files[0].Points)
+
+	assert.Equal(t, "b.txt", series.Files[1].File)
+	assert.Equal(t, []uint{1, 1}, series.Files[1].Values(Additions))
+	assert.Equal(t, []uint{1, 1}, series.Files[1].Values(Commits))
+}
+
+func TestChurnAggregatorSeriesFillsGaps(t *testing.T) {
+	aggregator := newChurnAggregator(ChurnOptions{Bucket: Week})
+	require.NoError(t, parseNumstat(strings.NewReader(numstatLog), aggregator.add))
+
+	series := aggregator.series(sortAndLimit(aggregator.result(), Changes, 1))
+
+	assert.Equal(t, []string{"2024-W01", "2024-W02", "2024-W03", "2024-W04", "2024-W05"}, series.Periods)
+	require.Len(t, series.Files, 1)
+	assert.Equal(t, []uint{6, 0, 0, 0, 0}, series.Files[0].Values(Changes))
+	assert.Equal(t, "2024-01-29", series.Files[0].Points[4].Start)
+}
+
+func TestReadChurnSeriesWeighted(t *testing.T) {
+	_, err := ReadChurnSeries(context.Background(), t.TempDir(), ChurnOptions{Bucket: Month, SortBy: Weighted, HalfLife: 30})
+	assert.ErrorContains(t, err, "--sort weighted can not be used with --bucket")
+}
+
+func TestPeriods(t *testing.T) {
+	tests := []struct {
+		bucket BucketType
+		date   string
+		start  string
+		name   string
+		next   string
+	}{
+		{bucket: Week, date: "2024-01-07", start: "2024-01-01", name: "2024-W01", next: "2024-01-08"},
+		{bucket: Week, date: "2021-01-01", start: "2020-12-28", name: "2020-W53", next: "2021-01-04"},
+		{bucket: Month, date: "2024-02-29", start: "2024-02-01", name: "2024-02", next: "2024-03-01"},
+		{bucket: Quarter, date: "2024-06-30", start: "2024-04-01", name: "2024-Q2", next: "2024-07-01"},
+		{bucket: Quarter, date: "2024-12-01", start: "2024-10-01", name: "2024-Q4", next: "2025-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bucket+" "+tt.date, func(t *testing.T) {
+			start := periodStart(date(tt.date), tt.bucket)
+			assert.Equal(t, date(tt.start), start)
+			assert.Equal(t, tt.name, periodName(start, tt.bucket))
+			assert.Equal(t, date(tt.next), nextPeriod(start, tt.bucket))
+		})
+	}
+}
+
+func TestPrintSeriesCSV(t *testing.T) {
+	var buf strings.Builder
+
+	aggregator := newChurnAggregator(ChurnOptions{Bucket: Quarter})
+	require.NoError(t, parseNumstat(strings.NewReader(numstatLog), aggregator.add))
+
+	require.NoError(t, printSeries(aggregator.series(sortAndLimit(aggregator.result(), Changes, 1)), &buf, ChurnOptions{OutputFormat: CSV}))
+
+	expected := "Period,Start,Changes,Additions,Deletions,Commits,FilePath\n" +
+		"2024-Q1,2024-01-01,6,5,1,3,dirb/f 1.txt\n"
+
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Minimal interval between progress reports
+var progressInterval = time.Second
+
+// streamCommand runs git command and parses its output while it is written.
+// Command is killed if ctx is done or parse fails.
+func streamCommand(ctx context.Context, cmd *exec.Cmd, parse func(io.Reader) error) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to execute git command: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("git command is interrupted: %w", ctx.Err())
+		}
+		return fmt.Errorf("failed to execute git command: %v", err)
+	}
+
+	parseErr := parse(stdout)
+	if parseErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("git command is interrupted: %w", ctx.Err())
+	}
+
+	if parseErr != nil {
+		return parseErr
+	}
+
+	if waitErr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to execute git command: %v: %s", waitErr, msg)
+		}
+		return fmt.Errorf("failed to execute git command: %v", waitErr)
+	}
+
+	return nil
+}
+
+// progress reports number of read commits, nothing is reported if out is nil
+type progress struct {
+	out   io.Writer
+	count int
+	start time.Time
+	last  time.Time
+}
+
+func newProgress(out io.Writer) *progress {
+	now := time.Now()
+	return &progress{out: out, start: now, last: now}
+}
+
+func (p *progress) commit() {
+	p.count++
+
+	if p.out != nil && time.Since(p.last) >= progressInterval {
+		p.last = time.Now()
+		fmt.Fprintf(p.out, "Read %d commits\n", p.count)
+	}
+}
+
+func (p *progress) done() {
+	if p.out != nil {
+		fmt.Fprintf(p.out, "Read %d commits in %s\n", p.count, time.Since(p.start).Round(time.Millisecond))
+	}
+}
+
+// wrap counts commits passed to fn
+func (p *progress) wrap(fn func(*commit) error) func(*commit) error {
+	return func(c *commit) error {
+		p.commit()
+		return fn(c)
+	}
+}
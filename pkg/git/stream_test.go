@@ -0,0 +1,66 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	Unbundle(t, "../../test/bundles/churn-test.bundle", tmpDir)
+
+	var out bytes.Buffer
+	err := streamCommand(context.Background(), exec.Command("git", "-C", tmpDir, "rev-list", "HEAD"), func(r io.Reader) error {
+		_, err := io.Copy(&out, r)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Len(t, bytes.Fields(out.Bytes()), 6)
+
+	err = streamCommand(context.Background(), exec.Command("git", "-C", tmpDir, "rev-list", "unknown"), func(r io.Reader) error {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	})
+	assert.ErrorContains(t, err, "unknown revision")
+
+	// Parse error stops reading and is returned as is
+	stop := errors.New("stop")
+	err = streamCommand(context.Background(), exec.Command("git", "-C", tmpDir, "log"), func(r io.Reader) error {
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ReadChurn(ctx, tmpDir, ChurnOptions{SortBy: Changes, Top: 10})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestProgress(t *testing.T) {
+	defer func(interval time.Duration) { progressInterval = interval }(progressInterval)
+	progressInterval = 0
+
+	var out bytes.Buffer
+	p := newProgress(&out)
+	fn := p.wrap(func(*commit) error { return nil })
+	require.NoError(t, fn(&commit{}))
+	require.NoError(t, fn(&commit{}))
+	p.done()
+
+	assert.Contains(t, out.String(), "Read 1 commits\nRead 2 commits\nRead 2 commits in ")
+
+	// Nothing is written without output
+	p = newProgress(nil)
+	p.commit()
+	p.done()
+	assert.Equal(t, 1, p.count)
+}
@@ -7,20 +7,46 @@ import (
 )
 
 func CreateCSVChart(entries []ScatterEntry, out io.Writer) error {
+	scorer, err := NewRiskScorer(RiskOpts, entries)
+	if err != nil {
+		return err
+	}
+
 	sort.Slice(entries, func(i, j int) bool {
-		scoreI := entries[i].Complexity * float64(entries[i].Churn)
-		scoreJ := entries[j].Complexity * float64(entries[j].Churn)
-		return scoreI > scoreJ
+		return scorer.Score(entries[i].ScatterData) > scorer.Score(entries[j].ScatterData)
 	})
 
-	fmt.Fprintf(out, "RiskScore,Complexity,Churn,FilePath\n")
+	if hasCoverage(entries) {
+		fmt.Fprintf(out, "RiskScore,RiskLevel,Complexity(%s),Churn,Coverage,FilePath\n", ComplexityAggregation)
+
+		for _, entry := range entries {
+			coverage := ""
+			if entry.HasCoverage {
+				coverage = fmt.Sprintf("%.2f", entry.Coverage)
+			}
+
+			score := scorer.Score(entry.ScatterData)
+			fmt.Fprintf(out, "%.2f,%s,%.2f,%s,%s,%s\n",
+				score,
+				riskLevelName(RiskLevels, score),
+				entry.Complexity,
+				FormatChurn(entry.Churn),
+				coverage,
+				entry.File)
+		}
+
+		return nil
+	}
+
+	fmt.Fprintf(out, "RiskScore,RiskLevel,Complexity(%s),Churn,FilePath\n", ComplexityAggregation)
 
 	for _, entry := range entries {
-		riskScore := entry.Complexity * float64(entry.Churn)
-		fmt.Fprintf(out, "%.2f,%.2f,%d,%s\n",
-			riskScore,
+		score := scorer.Score(entry.ScatterData)
+		fmt.Fprintf(out, "%.2f,%s,%.2f,%s,%s\n",
+			score,
+			riskLevelName(RiskLevels, score),
 			entry.Complexity,
-			entry.Churn,
+			FormatChurn(entry.Churn),
 			entry.File)
 	}
 
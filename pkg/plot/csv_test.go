@@ -0,0 +1,29 @@
+package plot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateCSVChart(t *testing.T) {
+	var buf bytes.Buffer
+
+	entries := []ScatterEntry{
+		{File: "a.go", ScatterData: ScatterData{Complexity: 2, Churn: 3}},
+		{File: "b.go", ScatterData: ScatterData{Complexity: 4, Churn: 5}},
+	}
+
+	assert.NoError(t, CreateCSVChart(entries, &buf))
+	assert.Equal(t, "RiskScore,RiskLevel,Complexity(mean),Churn,FilePath\n20.00,Very Low Risk,4.00,5,b.go\n6.00,Very Low Risk,2.00,3,a.go\n", buf.String())
+
+	buf.Reset()
+	entries = []ScatterEntry{
+		{File: "a.go", ScatterData: ScatterData{Complexity: 2, Churn: 3, Coverage: 0.5, HasCoverage: true}},
+		{File: "b.go", ScatterData: ScatterData{Complexity: 4, Churn: 5}},
+	}
+
+	assert.NoError(t, CreateCSVChart(entries, &buf))
+	assert.Equal(t, "RiskScore,RiskLevel,Complexity(mean),Churn,Coverage,FilePath\n20.00,Very Low Risk,4.00,5,,b.go\n3.00,Very Low Risk,2.00,3,0.50,a.go\n", buf.String())
+}
@@ -0,0 +1,257 @@
+package plot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// riskVars are metrics available in user-supplied risk expressions
+type riskVars struct {
+	Complexity float64
+	Churn      float64
+	Coverage   float64
+}
+
+type riskExpr func(vars riskVars) float64
+
+var exprVariables = map[string]func(vars riskVars) float64{
+	"complexity": func(vars riskVars) float64 { return vars.Complexity },
+	"churn":      func(vars riskVars) float64 { return vars.Churn },
+	"coverage":   func(vars riskVars) float64 { return vars.Coverage },
+}
+
+var exprFunctions = map[string]func(args []float64) float64{
+	"log":  func(args []float64) float64 { return math.Log(args[0]) },
+	"sqrt": func(args []float64) float64 { return math.Sqrt(args[0]) },
+	"abs":  func(args []float64) float64 { return math.Abs(args[0]) },
+	"min":  func(args []float64) float64 { return math.Min(args[0], args[1]) },
+	"max":  func(args []float64) float64 { return math.Max(args[0], args[1]) },
+}
+
+var exprArity = map[string]int{"log": 1, "sqrt": 1, "abs": 1, "min": 2, "max": 2}
+
+// parseRiskExpr compiles arithmetic expression with +, -, *, /, ^, parentheses,
+// metric variables and functions from exprFunctions
+func parseRiskExpr(input string) (riskExpr, error) {
+	p := &exprParser{input: input}
+	p.next()
+
+	expr, err := p.parseSum()
+	if err != nil {
+		return nil, fmt.Errorf("invalid risk expression %q: %w", input, err)
+	}
+
+	if p.token != "" {
+		return nil, fmt.Errorf("invalid risk expression %q: unexpected %q", input, p.token)
+	}
+
+	return expr, nil
+}
+
+type exprParser struct {
+	input string
+	pos   int
+	token string
+}
+
+// next reads the following token, empty token means end of input
+func (p *exprParser) next() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+
+	if p.pos >= len(p.input) {
+		p.token = ""
+		return
+	}
+
+	start := p.pos
+	c := rune(p.input[p.pos])
+	switch {
+	case unicode.IsDigit(c) || c == '.':
+		for p.pos < len(p.input) && (unicode.IsDigit(rune(p.input[p.pos])) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+	case unicode.IsLetter(c) || c == '_':
+		for p.pos < len(p.input) && (unicode.IsLetter(rune(p.input[p.pos])) || unicode.IsDigit(rune(p.input[p.pos])) || p.input[p.pos] == '_') {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+
+	p.token = p.input[start:p.pos]
+}
+
+func (p *exprParser) parseSum() (riskExpr, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.token == "+" || p.token == "-" {
+		op := p.token
+		p.next()
+
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		if op == "+" {
+			left = func(vars riskVars) float64 { return l(vars) + right(vars) }
+		} else {
+			left = func(vars riskVars) float64 { return l(vars) - right(vars) }
+		}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parseProduct() (riskExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.token == "*" || p.token == "/" {
+		op := p.token
+		p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		if op == "*" {
+			left = func(vars riskVars) float64 { return l(vars) * right(vars) }
+		} else {
+			left = func(vars riskVars) float64 { return l(vars) / right(vars) }
+		}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (riskExpr, error) {
+	if p.token == "-" {
+		p.next()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(vars riskVars) float64 { return -operand(vars) }, nil
+	}
+
+	return p.parsePower()
+}
+
+// parsePower handles right associative exponentiation
+func (p *exprParser) parsePower() (riskExpr, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.token != "^" {
+		return base, nil
+	}
+	p.next()
+
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(vars riskVars) float64 { return math.Pow(base(vars), exponent(vars)) }, nil
+}
+
+func (p *exprParser) parsePrimary() (riskExpr, error) {
+	token := p.token
+
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "(":
+		p.next()
+
+		expr, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.token != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.next()
+
+		return expr, nil
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token)
+		}
+		p.next()
+
+		return func(riskVars) float64 { return value }, nil
+	case unicode.IsLetter(rune(token[0])) || token[0] == '_':
+		p.next()
+
+		if p.token == "(" {
+			return p.parseCall(token)
+		}
+
+		variable, exists := exprVariables[strings.ToLower(token)]
+		if !exists {
+			return nil, fmt.Errorf("unknown variable %q, use one of the following: complexity, churn, coverage", token)
+		}
+
+		return variable, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+}
+
+func (p *exprParser) parseCall(name string) (riskExpr, error) {
+	fn, exists := exprFunctions[strings.ToLower(name)]
+	if !exists {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	p.next() // Skip opening parenthesis
+
+	args := make([]riskExpr, 0)
+	for p.token != ")" {
+		if len(args) > 0 {
+			if p.token != "," {
+				return nil, fmt.Errorf("expected ',' in arguments of %s", name)
+			}
+			p.next()
+		}
+
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+
+	if arity := exprArity[strings.ToLower(name)]; len(args) != arity {
+		return nil, fmt.Errorf("function %s expects %d arguments, got %d", name, arity, len(args))
+	}
+
+	return func(vars riskVars) float64 {
+		values := make([]float64, len(args))
+		for i, arg := range args {
+			values[i] = arg(vars)
+		}
+		return fn(values)
+	}, nil
+}
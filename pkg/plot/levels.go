@@ -0,0 +1,222 @@
+package plot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Category of entries which risk score is outside of all levels
+const unknownRisk = "Unknown"
+
+// RiskLevel is a named band of risk scores in range [Min, Max)
+type RiskLevel struct {
+	Name  string
+	Color string
+	Min   float64
+	Max   float64
+}
+
+// Levels used to categorize risk scores, sorted by Min.
+// Defaults are calibrated for default risk formula, commands replace them by DefaultRiskLevels
+// of the chosen formula unless levels are configured.
+var RiskLevels = DefaultRiskLevels(RiskOpts)
+
+// Names and colors of default levels, from the lowest to the highest risk
+var defaultLevelNames = []struct{ name, color string }{
+	{"Very Low Risk", "#90EE90"},
+	{"Low Risk", "#47d147"},
+	{"Medium Risk", "#ffd700"},
+	{"High Risk", "#ffa64d"},
+	{"Very High Risk", "#ff4d4d"},
+	{"Critical Risk", "#731343"},
+}
+
+// DefaultRiskLevels returns levels which bounds match range of scores of formula and normalization.
+// Raw product spans orders of magnitude, so its bounds grow geometrically, e.g. a file with
+// complexity 12 changed in 23 commits scores 276 and is Medium Risk. Scores of expressions are
+// not known in advance, they get bounds of product.
+func DefaultRiskLevels(opts RiskOptions) []RiskLevel {
+	normalized := opts.Normalization != "" && opts.Normalization != NormalizeNone
+
+	var bounds []float64
+	switch {
+	case opts.Formula == FormulaSum && !normalized:
+		bounds = []float64{20, 30, 45, 70, 100}
+	case opts.Formula == FormulaSum:
+		bounds = []float64{0.4, 0.7, 1, 1.3, 1.6}
+	case opts.Formula == FormulaGeoMean && !normalized:
+		bounds = []float64{10, 15, 20, 30, 40}
+	case opts.Formula == FormulaGeoMean:
+		bounds = []float64{0.2, 0.3, 0.45, 0.6, 0.75}
+	case normalized:
+		bounds = []float64{0.05, 0.1, 0.2, 0.35, 0.55}
+	default:
+		bounds = []float64{100, 200, 400, 800, 1600}
+	}
+
+	levels := make([]RiskLevel, len(defaultLevelNames))
+	for i, level := range defaultLevelNames {
+		levels[i] = RiskLevel{Name: level.name, Color: level.color, Max: math.Inf(1)}
+		if i > 0 {
+			levels[i].Min = bounds[i-1]
+		}
+		if i < len(bounds) {
+			levels[i].Max = bounds[i]
+		}
+	}
+
+	return levels
+}
+
+// riskLevelConfig is a level in config file, missing max means level has no upper bound
+type riskLevelConfig struct {
+	Name  string   `yaml:"name"`
+	Color string   `yaml:"color"`
+	Min   float64  `yaml:"min"`
+	Max   *float64 `yaml:"max"`
+}
+
+// LoadRiskLevels reads risk levels from YAML or JSON file
+func LoadRiskLevels(path string) ([]RiskLevel, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadRiskLevels(f)
+}
+
+// ReadRiskLevels parses list of levels, either top-level or under "levels" key:
+//
+//	levels:
+//	  - name: Low
+//	    color: "#47d147"
+//	    min: 0
+//	    max: 100
+//	  - name: High
+//	    color: "#ff4d4d"
+//	    min: 100
+func ReadRiskLevels(r io.Reader) ([]RiskLevel, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&node); err != nil {
+		return nil, fmt.Errorf("failed to parse risk levels: %w", err)
+	}
+
+	var configs []riskLevelConfig
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.SequenceNode {
+		err = node.Decode(&configs)
+	} else {
+		var file struct {
+			Levels []riskLevelConfig `yaml:"levels"`
+		}
+		err = node.Decode(&file)
+		configs = file.Levels
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse risk levels: %w", err)
+	}
+
+	return newRiskLevels(configs)
+}
+
+// newRiskLevels converts levels from config and validates them
+func newRiskLevels(configs []riskLevelConfig) ([]RiskLevel, error) {
+	levels := make([]RiskLevel, 0, len(configs))
+	for _, config := range configs {
+		level := RiskLevel{Name: config.Name, Color: config.Color, Min: config.Min, Max: math.Inf(1)}
+		if config.Max != nil {
+			level.Max = *config.Max
+		}
+		levels = append(levels, level)
+	}
+
+	if err := ValidateRiskLevels(levels); err != nil {
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+// ValidateRiskLevels checks that levels are named, sorted by bounds and do not overlap
+func ValidateRiskLevels(levels []RiskLevel) error {
+	if len(levels) == 0 {
+		return fmt.Errorf("at least one risk level is required")
+	}
+
+	names := make(map[string]bool)
+	for i, level := range levels {
+		if level.Name == "" {
+			return fmt.Errorf("risk level #%d has no name", i+1)
+		}
+		if level.Name == unknownRisk || names[level.Name] {
+			return fmt.Errorf("risk level name %q is reserved or duplicated", level.Name)
+		}
+		names[level.Name] = true
+
+		if level.Min >= level.Max {
+			return fmt.Errorf("%s min (%g) must be less than max (%g)", level.Name, level.Min, level.Max)
+		}
+
+		if i > 0 && levels[i-1].Max > level.Min {
+			return fmt.Errorf("%s max (%g) must not be greater than %s min (%g)",
+				levels[i-1].Name, levels[i-1].Max, level.Name, level.Min)
+		}
+	}
+
+	return nil
+}
+
+// findRiskLevel returns level which range contains score.
+// Max of the last level is inclusive, so unbounded level contains +Inf too.
+func findRiskLevel(levels []RiskLevel, score float64) (RiskLevel, bool) {
+	for i, level := range levels {
+		last := i == len(levels)-1 || math.IsInf(level.Max, 1)
+		if score >= level.Min && (score < level.Max || last && score <= level.Max) {
+			return level, true
+		}
+	}
+
+	return RiskLevel{}, false
+}
+
+// RiskLevelOf returns name of risk level of score
+func RiskLevelOf(score float64) string {
+	return riskLevelName(RiskLevels, score)
+}
+
+// LevelThreshold returns minimal score of named level, the highest level if name is empty
+func LevelThreshold(levels []RiskLevel, name string) (float64, error) {
+	if name == "" {
+		return levels[len(levels)-1].Min, nil
+	}
+
+	names := make([]string, 0, len(levels))
+	for _, level := range levels {
+		if level.Name == name {
+			return level.Min, nil
+		}
+		names = append(names, level.Name)
+	}
+
+	return 0, fmt.Errorf("unknown risk level %q, use one of the following: %v", name, names)
+}
+
+// riskLevelName returns name of level which range contains score or unknown category
+func riskLevelName(levels []RiskLevel, score float64) string {
+	if level, found := findRiskLevel(levels, score); found {
+		return level.Name
+	}
+
+	return unknownRisk
+}
@@ -0,0 +1,112 @@
+package plot
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRiskLevels(t *testing.T) {
+	yamlLevels := `
+levels:
+  - name: Fine
+    color: "#47d147"
+    min: 0
+    max: 100.5
+  - name: Hotspot
+    color: "#ff4d4d"
+    min: 100.5
+`
+	levels, err := ReadRiskLevels(strings.NewReader(yamlLevels))
+	require.NoError(t, err)
+	assert.Equal(t, []RiskLevel{
+		{Name: "Fine", Color: "#47d147", Min: 0, Max: 100.5},
+		{Name: "Hotspot", Color: "#ff4d4d", Min: 100.5, Max: math.Inf(1)},
+	}, levels)
+
+	jsonLevels := `[{"name": "Low", "color": "green", "min": 0, "max": 10}, {"name": "High", "color": "red", "min": 20}]`
+	levels, err = ReadRiskLevels(strings.NewReader(jsonLevels))
+	require.NoError(t, err)
+	require.Len(t, levels, 2)
+
+	assert.Equal(t, "Low", riskLevelName(levels, 9.99))
+	assert.Equal(t, "Unknown", riskLevelName(levels, 10))
+	assert.Equal(t, "High", riskLevelName(levels, 1e9))
+}
+
+func TestFindRiskLevel(t *testing.T) {
+	unbounded := []RiskLevel{{Name: "Low", Min: 0, Max: 10}, {Name: "High", Min: 10, Max: math.Inf(1)}}
+	bounded := []RiskLevel{{Name: "Low", Min: 0, Max: 10}, {Name: "High", Min: 10, Max: 20}}
+
+	tests := []struct {
+		name   string
+		levels []RiskLevel
+		score  float64
+		level  string
+	}{
+		{name: "min is inclusive", levels: unbounded, score: 10, level: "High"},
+		{name: "max is exclusive", levels: bounded, score: 9.99, level: "Low"},
+		{name: "infinite score in unbounded level", levels: unbounded, score: math.Inf(1), level: "High"},
+		{name: "max of last level is inclusive", levels: bounded, score: 20, level: "High"},
+		{name: "above last level", levels: bounded, score: 20.01, level: "Unknown"},
+		{name: "below first level", levels: bounded, score: -1, level: "Unknown"},
+		{name: "not a number", levels: unbounded, score: math.NaN(), level: "Unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.level, riskLevelName(tt.levels, tt.score))
+		})
+	}
+}
+
+func TestValidateRiskLevels(t *testing.T) {
+	assert.NoError(t, ValidateRiskLevels(RiskLevels))
+
+	tests := []struct {
+		name   string
+		levels []RiskLevel
+	}{
+		{name: "empty", levels: []RiskLevel{}},
+		{name: "unnamed", levels: []RiskLevel{{Min: 0, Max: 1}}},
+		{name: "duplicated", levels: []RiskLevel{{Name: "A", Min: 0, Max: 1}, {Name: "A", Min: 1, Max: 2}}},
+		{name: "empty range", levels: []RiskLevel{{Name: "A", Min: 1, Max: 1}}},
+		{name: "overlap", levels: []RiskLevel{{Name: "A", Min: 0, Max: 5}, {Name: "B", Min: 4, Max: 10}}},
+		{name: "unsorted", levels: []RiskLevel{{Name: "A", Min: 5, Max: 10}, {Name: "B", Min: 0, Max: 5}}},
+	}
+
+	for _, tt := range tests {
+		assert.Error(t, ValidateRiskLevels(tt.levels), tt.name)
+	}
+}
+
+func TestDefaultRiskLevels(t *testing.T) {
+	// Typical file: mean complexity 12 in 23 commits, normalized metrics around the median
+	typical := []ScatterEntry{
+		{ScatterData: ScatterData{Complexity: 2, Churn: 3}, File: "a.go"},
+		{ScatterData: ScatterData{Complexity: 12, Churn: 23}, File: "b.go"},
+		{ScatterData: ScatterData{Complexity: 30, Churn: 80}, File: "c.go"},
+	}
+
+	for _, formula := range []RiskFormula{FormulaProduct, FormulaSum, FormulaGeoMean} {
+		for _, normalization := range Normalizations {
+			opts := RiskOptions{Formula: formula, Normalization: normalization, ComplexityWeight: 1, ChurnWeight: 1}
+			t.Run(formula+"/"+normalization, func(t *testing.T) {
+				levels := DefaultRiskLevels(opts)
+				require.NoError(t, ValidateRiskLevels(levels))
+
+				scorer, err := NewRiskScorer(opts, typical)
+				require.NoError(t, err)
+
+				level, found := findRiskLevel(levels, scorer.Score(typical[1].ScatterData))
+				require.True(t, found)
+				assert.NotEqual(t, levels[len(levels)-1].Name, level.Name)
+			})
+		}
+	}
+
+	assert.Equal(t, DefaultRiskLevels(RiskOpts), RiskLevels)
+}
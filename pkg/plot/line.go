@@ -0,0 +1,78 @@
+package plot
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// LineSeries is a named series of values, one value per period
+type LineSeries struct {
+	Name   string
+	Values []uint
+}
+
+func formLineData(values []uint) []opts.LineData {
+	data := make([]opts.LineData, 0, len(values))
+	for _, value := range values {
+		data = append(data, opts.LineData{Value: value})
+	}
+
+	return data
+}
+
+// CreateLineChart draws series over periods, series are stacked as areas if Stacked is set
+func CreateLineChart(periods []string, series []LineSeries, metric string, outputPath string) error {
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title: fmt.Sprintf("Churn (%s) over time", metric),
+			Left:  "center",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:    opts.Bool(true),
+			Trigger: "axis",
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Show: opts.Bool(WithLegend),
+			Type: "scroll",
+			Top:  "bottom",
+		}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name: "Period",
+			Type: "category",
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: metric,
+			Type: "value",
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:  fmt.Sprintf("%dpx", WidthPx),
+			Height: fmt.Sprintf("%dpx", HeightPx),
+		}),
+	)
+
+	line.SetXAxis(periods)
+
+	for _, s := range series {
+		seriesOpts := []charts.SeriesOpts{charts.WithLabelOpts(opts.Label{Show: opts.Bool(false)})}
+		if Stacked {
+			seriesOpts = append(seriesOpts,
+				charts.WithLineChartOpts(opts.LineChart{Stack: "total"}),
+				charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: 0.6}),
+			)
+		}
+
+		line.AddSeries(s.Name, formLineData(s.Values), seriesOpts...)
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return line.Render(f)
+}
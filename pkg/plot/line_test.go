@@ -0,0 +1,38 @@
+package plot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormLineData(t *testing.T) {
+	data := formLineData([]uint{0, 3})
+
+	require.Len(t, data, 2)
+	assert.Equal(t, uint(0), data[0].Value)
+	assert.Equal(t, uint(3), data[1].Value)
+}
+
+func TestCreateLineChart(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "series.html")
+
+	series := []LineSeries{
+		{Name: "main.go", Values: []uint{4, 0, 7}},
+		{Name: "util.go", Values: []uint{1, 2, 0}},
+	}
+
+	Stacked = true
+	defer func() { Stacked = false }()
+
+	require.NoError(t, CreateLineChart([]string{"2024-01", "2024-02", "2024-03"}, series, "changes", output))
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "main.go")
+	assert.Contains(t, string(content), "2024-02")
+	assert.Contains(t, string(content), `"stack":"total"`)
+}
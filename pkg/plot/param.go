@@ -1,14 +1,5 @@
 package plot
 
-var (
-	VeryLowRisk  uint = 10
-	LowRisk      uint = 15
-	MediumRisk   uint = 20
-	HighRisk     uint = 25
-	VeryHighRisk uint = 30
-	CriticalRisk uint = 35
-)
-
 type OutputType = string
 
 var (
@@ -20,6 +11,21 @@ var (
 
 var OutputFormat = Tabular
 
+// ColorType represents what defines colour of points in scatter chart
+type ColorType = string
+
+var (
+	ColorNone     ColorType = "none"
+	ColorRisk     ColorType = "risk"
+	ColorCoverage ColorType = "coverage"
+	ColorTypes              = []ColorType{ColorNone, ColorRisk, ColorCoverage}
+)
+
+var ColorBy = ColorNone
+
+// Aggregation used to compute complexity of files, shown in chart headers
+var ComplexityAggregation = "mean"
+
 // If need to show scroll in chart
 var WithScroll = false
 
@@ -29,6 +35,9 @@ var WithRisks = false
 // If need to show legend in chart
 var WithLegend = true
 
+// If need to stack series of line chart as areas
+var Stacked = false
+
 // If need to show tooltip in chart
 var WithTooltip = true
 
@@ -36,7 +36,7 @@ func readCSVToChartEntries(filepath string) ([]ScatterEntry, error) {
 
 		entry := ScatterEntry{
 			File:        record[0],
-			ScatterData: ScatterData{Complexity: complexity, Churn: uint(churn)},
+			ScatterData: ScatterData{Complexity: complexity, Churn: float64(churn)},
 		}
 		entries = append(entries, entry)
 	}
@@ -45,7 +45,10 @@ func readCSVToChartEntries(filepath string) ([]ScatterEntry, error) {
 }
 
 func createTestChart(t *testing.T, entries []ScatterEntry, outputPath string) { 
-	err := CreateScatterChart(entries, NewRisksMapper(), outputPath)
+	scorer, err := NewRiskScorer(RiskOpts, entries)
+	assert.NoError(t, err)
+
+	err = CreateScatterChart(entries, NewRisksMapper(scorer), outputPath)
 	assert.NoError(t, err)
 
 	_, err = os.Stat(outputPath)
@@ -1,52 +1,17 @@
 package plot
 
 import (
-	"fmt"
-
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
-type RiskLevel struct {
-	Name  string
-	Color string
-	Min   uint
-	Max   uint
-}
-
-// Need to make it more general TODO refactor
-func ValidateRiskThresholds() error {
-	if VeryLowRisk >= LowRisk {
-		return fmt.Errorf("Very Low Risk threshold (%d) must be less than Low Risk threshold (%d)",
-			VeryLowRisk, LowRisk)
-	}
-	if LowRisk >= MediumRisk {
-		return fmt.Errorf("Low Risk threshold (%d) must be less than Medium Risk threshold (%d)",
-			LowRisk, MediumRisk)
-	}
-	if MediumRisk >= HighRisk {
-		return fmt.Errorf("Medium Risk threshold (%d) must be less than High Risk threshold (%d)",
-			MediumRisk, HighRisk)
-	}
-	if HighRisk >= VeryHighRisk {
-		return fmt.Errorf("High Risk threshold (%d) must be less than Very High Risk threshold (%d)",
-			HighRisk, VeryHighRisk)
-	}
-	if VeryHighRisk >= CriticalRisk {
-		return fmt.Errorf("Very High Risk threshold (%d) must be less than Critical Risk threshold (%d)",
-			VeryHighRisk, CriticalRisk)
+func hasCoverage(entries []ScatterEntry) bool {
+	for _, entry := range entries {
+		if entry.HasCoverage {
+			return true
+		}
 	}
-	return nil
-}
 
-func getRiskLevels() []RiskLevel {
-	return []RiskLevel{
-		{Name: "Very Low Risk", Color: "#90EE90", Min: VeryLowRisk, Max: LowRisk - 1},
-		{Name: "Low Risk", Color: "#47d147", Min: LowRisk, Max: MediumRisk - 1},
-		{Name: "Medium Risk", Color: "#ffd700", Min: MediumRisk, Max: HighRisk - 1},
-		{Name: "High Risk", Color: "#ffa64d", Min: HighRisk, Max: VeryHighRisk - 1},
-		{Name: "Very High Risk", Color: "#ff4d4d", Min: VeryHighRisk, Max: CriticalRisk - 1},
-		{Name: "Critical Risk", Color: "#731343", Min: CriticalRisk, Max: ^uint(0)},
-	}
+	return false
 }
 
 // deprecated TODO: delete!
@@ -60,26 +25,20 @@ func getRiskColors(levels []RiskLevel) []string {
 
 type RisksMapper struct {
 	levels []RiskLevel
+	scorer *RiskScorer
 }
 
-func NewRisksMapper() *RisksMapper {
+func NewRisksMapper(scorer *RiskScorer) *RisksMapper {
 	return &RisksMapper{
-		levels: getRiskLevels(),
+		levels: RiskLevels,
+		scorer: scorer,
 	}
 }
 
 var _ EntryMapper = (*RisksMapper)(nil)
 
 func (rm *RisksMapper) Map(data ScatterData) Category {
-	riskScore := data.Complexity + float64(data.Churn)
-
-	for _, level := range rm.levels {
-		if riskScore >= float64(level.Min) && riskScore <= float64(level.Max) {
-			return level.Name
-		}
-	}
-
-	return "Unknown"
+	return riskLevelName(rm.levels, rm.scorer.Score(data))
 }
 
 func (rm *RisksMapper) Style(category Category) opts.ItemStyle {
@@ -107,3 +66,49 @@ func (rm *NoopMapper) Style(category Category) opts.ItemStyle {
 		Color: "#731343",
 	}
 }
+
+// CoverageMapper colours entries by share of covered lines
+type CoverageMapper struct{}
+
+var _ EntryMapper = (*CoverageMapper)(nil)
+
+var coverageLevels = []struct {
+	Name  string
+	Color string
+	Min   float64
+}{
+	{Name: "Coverage >= 75%", Color: "#47d147", Min: 0.75},
+	{Name: "Coverage 50-75%", Color: "#ffd700", Min: 0.5},
+	{Name: "Coverage 25-50%", Color: "#ffa64d", Min: 0.25},
+	{Name: "Coverage < 25%", Color: "#ff4d4d", Min: 0},
+}
+
+const noCoverage = "No coverage data"
+
+func (cm *CoverageMapper) Map(data ScatterData) Category {
+	if !data.HasCoverage {
+		return noCoverage
+	}
+
+	for _, level := range coverageLevels {
+		if data.Coverage >= level.Min {
+			return level.Name
+		}
+	}
+
+	return noCoverage
+}
+
+func (cm *CoverageMapper) Style(category Category) opts.ItemStyle {
+	for _, level := range coverageLevels {
+		if level.Name == category {
+			return opts.ItemStyle{
+				Color: level.Color,
+			}
+		}
+	}
+
+	return opts.ItemStyle{
+		Color: "#a0a0a0",
+	}
+}
@@ -0,0 +1,19 @@
+package plot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageMapper(t *testing.T) {
+	mapper := &CoverageMapper{}
+
+	assert.Equal(t, "Coverage >= 75%", mapper.Map(ScatterData{Coverage: 0.8, HasCoverage: true}))
+	assert.Equal(t, "Coverage 25-50%", mapper.Map(ScatterData{Coverage: 0.25, HasCoverage: true}))
+	assert.Equal(t, "Coverage < 25%", mapper.Map(ScatterData{Coverage: 0, HasCoverage: true}))
+	assert.Equal(t, "No coverage data", mapper.Map(ScatterData{Coverage: 0.9}))
+
+	assert.Equal(t, "#ffd700", mapper.Style("Coverage 50-75%").Color)
+	assert.Equal(t, "#a0a0a0", mapper.Style("No coverage data").Color)
+}
@@ -12,7 +12,10 @@ import (
 
 type ScatterData struct {
 	Complexity float64
-	Churn      uint
+	Churn      float64
+	// Share of covered lines in range [0, 1], valid only if HasCoverage is set
+	Coverage    float64
+	HasCoverage bool
 }
 
 type ScatterEntry struct {
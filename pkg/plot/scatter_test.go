@@ -167,31 +167,31 @@ func TestFormDataSeries(t *testing.T) {
 			want: ScatterSeries{
 				"critical": []opts.ScatterData{
 					{
-						Value:      []interface{}{12.0, uint(5), "critical1.go<br/>critical2.go"},
+						Value:      []interface{}{12.0, 5.0, "critical1.go<br/>critical2.go"},
 						Symbol:     "circle",
 						SymbolSize: ScatterSymbolSize,
 					},
 				},
 				"warning": []opts.ScatterData{
 					{
-						Value:      []interface{}{7.0, uint(3), "warning.go"},
+						Value:      []interface{}{7.0, 3.0, "warning.go"},
 						Symbol:     "circle",
 						SymbolSize: ScatterSymbolSize,
 					},
 				},
 				"normal": []opts.ScatterData{
 					{
-						Value:      []interface{}{3.0, uint(1), "normal1.go"},
+						Value:      []interface{}{3.0, 1.0, "normal1.go"},
 						Symbol:     "circle",
 						SymbolSize: ScatterSymbolSize,
 					},
 					{
-						Value:      []interface{}{1.0, uint(1), "normal2.go"},
+						Value:      []interface{}{1.0, 1.0, "normal2.go"},
 						Symbol:     "circle",
 						SymbolSize: ScatterSymbolSize,
 					},
 					{
-						Value:      []interface{}{2.0, uint(1), "normal3.go"},
+						Value:      []interface{}{2.0, 1.0, "normal3.go"},
 						Symbol:     "circle",
 						SymbolSize: ScatterSymbolSize,
 					},
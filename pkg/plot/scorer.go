@@ -0,0 +1,246 @@
+package plot
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// RiskFormula represents how metrics are combined into risk score
+type RiskFormula = string
+
+var (
+	FormulaProduct RiskFormula = "product"
+	FormulaSum     RiskFormula = "sum"
+	FormulaGeoMean RiskFormula = "geomean"
+	FormulaExpr    RiskFormula = "expr"
+	RiskFormulas               = []RiskFormula{FormulaProduct, FormulaSum, FormulaGeoMean, FormulaExpr}
+)
+
+// Normalization represents how complexity and churn are scaled before they are combined
+type Normalization = string
+
+var (
+	NormalizeNone       Normalization = "none"
+	NormalizeMinMax     Normalization = "minmax"
+	NormalizePercentile Normalization = "percentile"
+	NormalizeZScore     Normalization = "zscore"
+	Normalizations                    = []Normalization{NormalizeNone, NormalizeMinMax, NormalizePercentile, NormalizeZScore}
+)
+
+type RiskOptions struct {
+	Formula RiskFormula
+	// Expression over complexity, churn and coverage used by expr formula
+	Expression    string
+	Normalization Normalization
+	// Weights of metrics in geometric mean
+	ComplexityWeight float64
+	ChurnWeight      float64
+}
+
+var RiskOpts = RiskOptions{
+	Formula:          FormulaProduct,
+	Expression:       "",
+	Normalization:    NormalizeNone,
+	ComplexityWeight: 1,
+	ChurnWeight:      1,
+}
+
+// RiskScorer computes risk score of entries, it is shared by all outputs so they rank entries equally.
+// Normalization depends on all entries, so scorer is created for the set of entries it scores.
+type RiskScorer struct {
+	opts       RiskOptions
+	expr       riskExpr
+	complexity func(float64) float64
+	churn      func(float64) float64
+}
+
+func ValidateRiskOptions(opts RiskOptions) error {
+	_, err := NewRiskScorer(opts, nil)
+	return err
+}
+
+func NewRiskScorer(opts RiskOptions, entries []ScatterEntry) (*RiskScorer, error) {
+	scorer := &RiskScorer{opts: opts}
+
+	switch opts.Formula {
+	case FormulaProduct, FormulaSum:
+	case FormulaGeoMean:
+		if opts.ComplexityWeight < 0 || opts.ChurnWeight < 0 || opts.ComplexityWeight+opts.ChurnWeight == 0 {
+			return nil, fmt.Errorf("weights of geometric mean must be non-negative and not both zero")
+		}
+	case FormulaExpr:
+		if opts.Expression == "" {
+			return nil, fmt.Errorf("risk expression is required for %s formula", FormulaExpr)
+		}
+
+		expr, err := parseRiskExpr(opts.Expression)
+		if err != nil {
+			return nil, err
+		}
+		scorer.expr = expr
+	default:
+		return nil, fmt.Errorf("invalid risk formula: %s, use one of the following: %v", opts.Formula, RiskFormulas)
+	}
+
+	complexities := make([]float64, 0, len(entries))
+	churns := make([]float64, 0, len(entries))
+	for _, entry := range entries {
+		complexities = append(complexities, entry.Complexity)
+		churns = append(churns, entry.Churn)
+	}
+
+	var err error
+	if scorer.complexity, err = newNormalizer(opts.Normalization, complexities); err != nil {
+		return nil, err
+	}
+	if scorer.churn, err = newNormalizer(opts.Normalization, churns); err != nil {
+		return nil, err
+	}
+
+	return scorer, nil
+}
+
+// Score combines metrics of entry, score of partially tested code is scaled by share of uncovered lines.
+// Expressions get coverage as variable instead, unknown coverage is zero.
+func (s *RiskScorer) Score(data ScatterData) float64 {
+	complexity := s.complexity(data.Complexity)
+	churn := s.churn(data.Churn)
+
+	var score float64
+	switch s.opts.Formula {
+	case FormulaProduct:
+		score = complexity * churn
+	case FormulaSum:
+		score = complexity + churn
+	case FormulaGeoMean:
+		score = weightedGeoMean(complexity, churn, s.opts.ComplexityWeight, s.opts.ChurnWeight)
+	case FormulaExpr:
+		vars := riskVars{Complexity: complexity, Churn: churn}
+		if data.HasCoverage {
+			vars.Coverage = data.Coverage
+		}
+
+		score = s.expr(vars)
+		if math.IsNaN(score) {
+			return 0
+		}
+		return score
+	}
+
+	if data.HasCoverage {
+		score *= 1 - data.Coverage
+	}
+
+	return score
+}
+
+// Describe prints formula of score for headers of charts
+func (s *RiskScorer) Describe(withCoverage bool) string {
+	metric := func(name string) string {
+		if s.opts.Normalization == NormalizeNone {
+			return name
+		}
+		return fmt.Sprintf("%s(%s)", s.opts.Normalization, name)
+	}
+
+	var formula string
+	switch s.opts.Formula {
+	case FormulaProduct:
+		formula = metric("Complexity") + " * " + metric("Churn")
+	case FormulaSum:
+		formula = metric("Complexity") + " + " + metric("Churn")
+		if withCoverage {
+			formula = "(" + formula + ")"
+		}
+	case FormulaGeoMean:
+		total := s.opts.ComplexityWeight + s.opts.ChurnWeight
+		formula = fmt.Sprintf("%s^%.2f * %s^%.2f",
+			metric("Complexity"), s.opts.ComplexityWeight/total, metric("Churn"), s.opts.ChurnWeight/total)
+	case FormulaExpr:
+		formula = strings.TrimSpace(s.opts.Expression)
+		if s.opts.Normalization != NormalizeNone {
+			formula += fmt.Sprintf(", %s normalized", s.opts.Normalization)
+		}
+		return formula
+	}
+
+	if withCoverage {
+		formula += " * (1 - Coverage)"
+	}
+
+	return formula
+}
+
+func weightedGeoMean(a, b, weightA, weightB float64) float64 {
+	var logSum float64
+	for _, term := range []struct{ value, weight float64 }{{a, weightA}, {b, weightB}} {
+		if term.weight == 0 {
+			continue
+		}
+		if term.value <= 0 {
+			return 0
+		}
+		logSum += term.weight * math.Log(term.value)
+	}
+
+	return math.Exp(logSum / (weightA + weightB))
+}
+
+// newNormalizer returns function that scales metric relative to all its values
+func newNormalizer(normalization Normalization, values []float64) (func(float64) float64, error) {
+	switch normalization {
+	case NormalizeNone:
+		return func(value float64) float64 { return value }, nil
+	case NormalizeMinMax:
+		if len(values) == 0 {
+			return func(float64) float64 { return 1 }, nil
+		}
+
+		low, high := values[0], values[0]
+		for _, value := range values {
+			low, high = math.Min(low, value), math.Max(high, value)
+		}
+
+		return func(value float64) float64 {
+			if high == low {
+				return 1
+			}
+			return (value - low) / (high - low)
+		}, nil
+	case NormalizePercentile:
+		sorted := append([]float64{}, values...)
+		sort.Float64s(sorted)
+
+		// Share of values less than or equal to value
+		return func(value float64) float64 {
+			if len(sorted) == 0 {
+				return 1
+			}
+			rank := sort.Search(len(sorted), func(i int) bool { return sorted[i] > value })
+			return float64(rank) / float64(len(sorted))
+		}, nil
+	case NormalizeZScore:
+		var mean, variance float64
+		for _, value := range values {
+			mean += value
+		}
+		mean /= math.Max(float64(len(values)), 1)
+
+		for _, value := range values {
+			variance += (value - mean) * (value - mean)
+		}
+		stddev := math.Sqrt(variance / math.Max(float64(len(values)), 1))
+
+		// Signed z-scores break product and geometric mean, so they are mapped to (0, 1) by normal CDF
+		return func(value float64) float64 {
+			if stddev == 0 {
+				return 0.5
+			}
+			return 0.5 * (1 + math.Erf((value-mean)/stddev/math.Sqrt2))
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid normalization: %s, use one of the following: %v", normalization, Normalizations)
+	}
+}
@@ -0,0 +1,111 @@
+package plot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiskScorerFormulas(t *testing.T) {
+	data := ScatterData{Complexity: 4, Churn: 9}
+	tested := ScatterData{Complexity: 4, Churn: 9, Coverage: 0.5, HasCoverage: true}
+
+	tests := []struct {
+		opts         RiskOptions
+		want, tested float64
+	}{
+		{opts: RiskOptions{Formula: FormulaProduct, Normalization: NormalizeNone}, want: 36, tested: 18},
+		{opts: RiskOptions{Formula: FormulaSum, Normalization: NormalizeNone}, want: 13, tested: 6.5},
+		{opts: RiskOptions{Formula: FormulaGeoMean, Normalization: NormalizeNone, ComplexityWeight: 1, ChurnWeight: 1}, want: 6, tested: 3},
+		{opts: RiskOptions{Formula: FormulaGeoMean, Normalization: NormalizeNone, ComplexityWeight: 1, ChurnWeight: 0}, want: 4, tested: 2},
+		{opts: RiskOptions{Formula: FormulaExpr, Expression: "complexity^2 * (1 - coverage) + churn", Normalization: NormalizeNone}, want: 25, tested: 17},
+	}
+
+	for _, tt := range tests {
+		scorer, err := NewRiskScorer(tt.opts, nil)
+		require.NoError(t, err, tt.opts.Formula)
+
+		assert.InDelta(t, tt.want, scorer.Score(data), 1e-9, tt.opts.Formula)
+		assert.InDelta(t, tt.tested, scorer.Score(tested), 1e-9, tt.opts.Formula)
+	}
+}
+
+func TestRiskScorerNormalization(t *testing.T) {
+	entries := []ScatterEntry{
+		{File: "a", ScatterData: ScatterData{Complexity: 2, Churn: 100}},
+		{File: "b", ScatterData: ScatterData{Complexity: 4, Churn: 300}},
+		{File: "c", ScatterData: ScatterData{Complexity: 6, Churn: 500}},
+	}
+
+	score := func(normalization Normalization, data ScatterData) float64 {
+		scorer, err := NewRiskScorer(RiskOptions{Formula: FormulaSum, Normalization: normalization}, entries)
+		require.NoError(t, err)
+		return scorer.Score(data)
+	}
+
+	assert.InDelta(t, 1.0, score(NormalizeMinMax, entries[1].ScatterData), 1e-9)
+	assert.InDelta(t, 2.0, score(NormalizeMinMax, entries[2].ScatterData), 1e-9)
+	assert.InDelta(t, 4.0/3, score(NormalizePercentile, entries[1].ScatterData), 1e-9)
+	assert.InDelta(t, 1.0, score(NormalizeZScore, entries[1].ScatterData), 1e-9)
+	assert.InDelta(t, 2*0.8897, score(NormalizeZScore, entries[2].ScatterData), 1e-4)
+
+	// Equal values do not zero the metric
+	scorer, err := NewRiskScorer(RiskOptions{Formula: FormulaProduct, Normalization: NormalizeMinMax}, entries[:1])
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, scorer.Score(entries[0].ScatterData))
+}
+
+func TestRiskScorerZScoreRanking(t *testing.T) {
+	entries := []ScatterEntry{
+		{File: "below", ScatterData: ScatterData{Complexity: 1, Churn: 1}},
+		{File: "above", ScatterData: ScatterData{Complexity: 5, Churn: 3}},
+		{File: "c", ScatterData: ScatterData{Complexity: 3, Churn: 5}},
+		{File: "d", ScatterData: ScatterData{Complexity: 3, Churn: 3}},
+	}
+
+	// File below average on both metrics ranks lower than file above average on one and average on the other
+	for _, formula := range []RiskFormula{FormulaProduct, FormulaSum, FormulaGeoMean} {
+		scorer, err := NewRiskScorer(RiskOptions{Formula: formula, Normalization: NormalizeZScore, ComplexityWeight: 1, ChurnWeight: 1}, entries)
+		require.NoError(t, err)
+
+		below, above := scorer.Score(entries[0].ScatterData), scorer.Score(entries[1].ScatterData)
+		assert.Greater(t, above, below, formula)
+		assert.Greater(t, below, 0.0, formula)
+	}
+}
+
+func TestRiskScorerErrors(t *testing.T) {
+	assert.Error(t, ValidateRiskOptions(RiskOptions{Formula: "unknown", Normalization: NormalizeNone}))
+	assert.Error(t, ValidateRiskOptions(RiskOptions{Formula: FormulaSum, Normalization: "unknown"}))
+	assert.Error(t, ValidateRiskOptions(RiskOptions{Formula: FormulaExpr, Normalization: NormalizeNone}))
+	assert.Error(t, ValidateRiskOptions(RiskOptions{Formula: FormulaGeoMean, Normalization: NormalizeNone}))
+}
+
+func TestParseRiskExpr(t *testing.T) {
+	vars := riskVars{Complexity: 3, Churn: 4, Coverage: 0.25}
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{expr: "1 + 2 * 3", want: 7},
+		{expr: "(1 + 2) * 3", want: 9},
+		{expr: "2 ^ 3 ^ 2", want: 512},
+		{expr: "-2 ^ 2", want: -4},
+		{expr: "churn / 2 - complexity", want: -1},
+		{expr: "sqrt(churn) * max(complexity, 1.5)", want: 6},
+		{expr: "Complexity * (1 - coverage)", want: 2.25},
+	}
+
+	for _, tt := range tests {
+		expr, err := parseRiskExpr(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.InDelta(t, tt.want, expr(vars), 1e-9, tt.expr)
+	}
+
+	for _, invalid := range []string{"", "complexity +", "(churn", "loc * 2", "pow(2, 3)", "max(1)", "1 2", "churn $ 2"} {
+		_, err := parseRiskExpr(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
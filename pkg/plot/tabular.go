@@ -4,29 +4,67 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
 )
 
 func CreateTableChart(entries []ScatterEntry, out io.Writer) error {
+	scorer, err := NewRiskScorer(RiskOpts, entries)
+	if err != nil {
+		return err
+	}
+
 	sort.Slice(entries, func(i, j int) bool {
-		scoreI := entries[i].Complexity * float64(entries[i].Churn)
-		scoreJ := entries[j].Complexity * float64(entries[j].Churn)
-		return scoreI > scoreJ // Sort in descending order
+		return scorer.Score(entries[i].ScatterData) > scorer.Score(entries[j].ScatterData) // Sort in descending order
 	})
 
-	fmt.Fprintln(out, "\nFiles ranked by risk score (Complexity * Churn):")
+	if hasCoverage(entries) {
+		fmt.Fprintf(out, "\nFiles ranked by risk score (%s), complexity aggregation: %s\n", scorer.Describe(true), ComplexityAggregation)
+		fmt.Fprintln(out, strings.Repeat("-", 100))
+		fmt.Fprintf(out, "%-12s %-16s %-12s %-12s %-12s %-s\n", "RISK SCORE", "RISK LEVEL", "COMPLEXITY", "CHURN", "COVERAGE", "FILEPATH")
+		fmt.Fprintln(out, strings.Repeat("-", 100))
+
+		for _, entry := range entries {
+			score := scorer.Score(entry.ScatterData)
+			fmt.Fprintf(out, "%-12.2f %-16s %-12.2f %-12s %-12s %s\n",
+				score,
+				riskLevelName(RiskLevels, score),
+				entry.Complexity,
+				FormatChurn(entry.Churn),
+				formatCoverage(entry.ScatterData),
+				entry.File)
+		}
+
+		return nil
+	}
+
+	fmt.Fprintf(out, "\nFiles ranked by risk score (%s), complexity aggregation: %s\n", scorer.Describe(false), ComplexityAggregation)
 	fmt.Fprintln(out, strings.Repeat("-", 100))
-	fmt.Fprintf(out, "%-12s %-12s %-12s %-s\n", "RISK SCORE", "COMPLEXITY", "CHURN", "FILEPATH")
+	fmt.Fprintf(out, "%-12s %-16s %-12s %-12s %-s\n", "RISK SCORE", "RISK LEVEL", "COMPLEXITY", "CHURN", "FILEPATH")
 	fmt.Fprintln(out, strings.Repeat("-", 100))
 
 	for _, entry := range entries {
-		riskScore := entry.Complexity * float64(entry.Churn)
-		fmt.Fprintf(out, "%-12.2f %-12.2f %-12d %s\n",
-			riskScore,
+		score := scorer.Score(entry.ScatterData)
+		fmt.Fprintf(out, "%-12.2f %-16s %-12.2f %-12s %s\n",
+			score,
+			riskLevelName(RiskLevels, score),
 			entry.Complexity,
-			entry.Churn,
+			FormatChurn(entry.Churn),
 			entry.File)
 	}
 
 	return nil
 }
+
+// formatCoverage prints coverage in percents or dash if it is unknown
+func formatCoverage(data ScatterData) string {
+	if !data.HasCoverage {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", data.Coverage*100)
+}
+
+// FormatChurn prints churn without fraction if it is whole, weighted churn keeps its fraction
+func FormatChurn(churn float64) string {
+	return strconv.FormatFloat(churn, 'f', -1, 64)
+}
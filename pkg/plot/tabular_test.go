@@ -0,0 +1,31 @@
+package plot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTableChartCoverage(t *testing.T) {
+	var buf bytes.Buffer
+
+	entries := []ScatterEntry{
+		{File: "tested.go", ScatterData: ScatterData{Complexity: 10, Churn: 10, Coverage: 0.9, HasCoverage: true}},
+		{File: "untested.go", ScatterData: ScatterData{Complexity: 5, Churn: 10, Coverage: 0, HasCoverage: true}},
+		{File: "unknown.go", ScatterData: ScatterData{Complexity: 2, Churn: 10}},
+	}
+
+	assert.NoError(t, CreateTableChart(entries, &buf))
+
+	expected := "\nFiles ranked by risk score (Complexity * Churn * (1 - Coverage)), complexity aggregation: mean\n" +
+		strings.Repeat("-", 100) + "\n" +
+		"RISK SCORE   RISK LEVEL       COMPLEXITY   CHURN        COVERAGE     FILEPATH\n" +
+		strings.Repeat("-", 100) + "\n" +
+		"50.00        Very Low Risk    5.00         10           0%           untested.go\n" +
+		"20.00        Very Low Risk    2.00         10           -            unknown.go\n" +
+		"10.00        Very Low Risk    10.00        10           90%          tested.go\n"
+
+	assert.Equal(t, expected, buf.String())
+}
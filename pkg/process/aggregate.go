@@ -0,0 +1,149 @@
+package process
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/vbvictor/ccv/pkg/complexity"
+)
+
+// AggregationType represents how complexities of functions are combined into complexity of file
+type AggregationType = string
+
+var (
+	Mean           AggregationType = "mean"
+	Max            AggregationType = "max"
+	Sum            AggregationType = "sum"
+	WeightedMean   AggregationType = "weighted-mean"
+	P90            AggregationType = "p90"
+	AboveThreshold AggregationType = "above-threshold"
+	Aggregations                   = []AggregationType{Mean, Max, Sum, WeightedMean, P90, AboveThreshold}
+)
+
+type AggregateOptions struct {
+	Type AggregationType
+	// Functions with greater complexity are counted by above-threshold aggregation
+	Threshold uint
+}
+
+var AggregateOpts = AggregateOptions{
+	Type:      Mean,
+	Threshold: 15,
+}
+
+// String describes aggregation for headers of charts
+func (o AggregateOptions) String() string {
+	if o.Type == AboveThreshold {
+		return fmt.Sprintf("%s(%d)", o.Type, o.Threshold)
+	}
+	return o.Type
+}
+
+func ValidateAggregation() error {
+	for _, aggregation := range Aggregations {
+		if AggregateOpts.Type == aggregation {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid complexity aggregation: %s, use one of the following: %v", AggregateOpts.Type, Aggregations)
+}
+
+// AggregateComplexity calculates complexity of every file with functions using AggregateOpts
+func AggregateComplexity(files complexity.FilesStat) []FileComplexity {
+	return aggregateComplexity(files, AggregateOpts)
+}
+
+// aggregateComplexity calculates complexity of every file with functions using chosen aggregation
+func aggregateComplexity(files complexity.FilesStat, opts AggregateOptions) []FileComplexity {
+	if opts.Type == Mean {
+		return avgComplexity(files)
+	}
+
+	result := make([]FileComplexity, 0, len(files))
+
+	for _, file := range files {
+		if len(file.Functions) == 0 {
+			continue
+		}
+
+		var value float64
+		switch opts.Type {
+		case Max:
+			value = maxComplexity(file.Functions)
+		case Sum:
+			value = sumComplexity(file.Functions)
+		case WeightedMean:
+			value = weightedMeanComplexity(file.Functions)
+		case P90:
+			value = percentileComplexity(file.Functions, 90)
+		case AboveThreshold:
+			value = countAbove(file.Functions, opts.Threshold)
+		default:
+			panic("Unknown complexity aggregation")
+		}
+
+		if Verbose {
+			fmt.Printf("File: %s, Complexity (%s): %f\n", file.Path, opts, value)
+		}
+
+		result = append(result, FileComplexity{
+			File:        file.Path,
+			Complexity:  value,
+			Coverage:    file.Coverage,
+			HasCoverage: file.HasCoverage,
+		})
+	}
+
+	return result
+}
+
+func maxComplexity(functions []complexity.FunctionStat) float64 {
+	var result uint
+	for _, fn := range functions {
+		result = max(result, fn.Compexity)
+	}
+	return float64(result)
+}
+
+func sumComplexity(functions []complexity.FunctionStat) float64 {
+	var result float64
+	for _, fn := range functions {
+		result += float64(fn.Compexity)
+	}
+	return result
+}
+
+// weightedMeanComplexity weights functions by their length, so long functions affect file more than getters
+func weightedMeanComplexity(functions []complexity.FunctionStat) float64 {
+	var total, weights float64
+	for _, fn := range functions {
+		weight := float64(max(fn.Length, 1))
+		total += float64(fn.Compexity) * weight
+		weights += weight
+	}
+	return total / weights
+}
+
+// percentileComplexity uses nearest-rank method
+func percentileComplexity(functions []complexity.FunctionStat, percentile float64) float64 {
+	values := make([]uint, 0, len(functions))
+	for _, fn := range functions {
+		values = append(values, fn.Compexity)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	rank := int(math.Ceil(percentile / 100 * float64(len(values))))
+	return float64(values[max(rank, 1)-1])
+}
+
+func countAbove(functions []complexity.FunctionStat, threshold uint) float64 {
+	var result float64
+	for _, fn := range functions {
+		if fn.Compexity > threshold {
+			result++
+		}
+	}
+	return result
+}
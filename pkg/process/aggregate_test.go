@@ -0,0 +1,58 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vbvictor/ccv/pkg/complexity"
+)
+
+func TestAggregateComplexity(t *testing.T) {
+	files := complexity.FilesStat{
+		&complexity.FileStat{
+			Path: "file.go",
+			Functions: []complexity.FunctionStat{
+				{Name: "get1", Compexity: 1, Length: 3},
+				{Name: "get2", Compexity: 1, Length: 3},
+				{Name: "get3", Compexity: 1, Length: 3},
+				{Name: "huge", Compexity: 30, Length: 91},
+			},
+		},
+		&complexity.FileStat{Path: "empty.go"},
+	}
+
+	tests := []struct {
+		opts AggregateOptions
+		want float64
+	}{
+		{opts: AggregateOptions{Type: Mean}, want: 8.25},
+		{opts: AggregateOptions{Type: Max}, want: 30},
+		{opts: AggregateOptions{Type: Sum}, want: 33},
+		{opts: AggregateOptions{Type: WeightedMean}, want: 27.39},
+		{opts: AggregateOptions{Type: P90}, want: 30},
+		{opts: AggregateOptions{Type: AboveThreshold, Threshold: 15}, want: 1},
+		{opts: AggregateOptions{Type: AboveThreshold, Threshold: 30}, want: 0},
+	}
+
+	for _, tt := range tests {
+		got := aggregateComplexity(files, tt.opts)
+		assert.Len(t, got, 1, tt.opts.String())
+		assert.InDelta(t, tt.want, got[0].Complexity, 0.01, tt.opts.String())
+	}
+}
+
+func TestPercentileComplexity(t *testing.T) {
+	functions := make([]complexity.FunctionStat, 0)
+	for i := uint(10); i >= 1; i-- {
+		functions = append(functions, complexity.FunctionStat{Compexity: i})
+	}
+
+	assert.Equal(t, 9.0, percentileComplexity(functions, 90))
+	assert.Equal(t, 5.0, percentileComplexity(functions, 50))
+	assert.Equal(t, 3.0, percentileComplexity(functions[7:], 90))
+}
+
+func TestAggregateOptionsString(t *testing.T) {
+	assert.Equal(t, "p90", AggregateOptions{Type: P90, Threshold: 15}.String())
+	assert.Equal(t, "above-threshold(15)", AggregateOptions{Type: AboveThreshold, Threshold: 15}.String())
+}
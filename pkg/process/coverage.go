@@ -0,0 +1,37 @@
+package process
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/vbvictor/ccv/pkg/complexity"
+	"github.com/vbvictor/ccv/pkg/coverage"
+)
+
+// AttachCoverage sets line coverage of files and their functions found in report.
+// Files missing in report are left without coverage.
+func AttachCoverage(files complexity.FilesStat, report coverage.Report) {
+	for _, file := range files {
+		fileCoverage, found := report.Find(file.Path)
+		if !found {
+			if Verbose {
+				fmt.Printf("No coverage found for file: %s\n", file.Path)
+			}
+			continue
+		}
+
+		file.Coverage = roundRatio(fileCoverage.Ratio())
+		file.HasCoverage = true
+
+		for i := range file.Functions {
+			fn := &file.Functions[i]
+			ratio, ok := fileCoverage.RangeRatio(fn.Line, fn.Line+max(fn.Length, 1)-1)
+			fn.Coverage = roundRatio(ratio)
+			fn.HasCoverage = ok
+		}
+	}
+}
+
+func roundRatio(ratio float64) float64 {
+	return math.Round(ratio*100) / 100
+}
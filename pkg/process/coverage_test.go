@@ -0,0 +1,57 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vbvictor/ccv/pkg/complexity"
+	"github.com/vbvictor/ccv/pkg/coverage"
+	"github.com/vbvictor/ccv/pkg/plot"
+)
+
+func TestAttachCoverage(t *testing.T) {
+	files := complexity.FilesStat{
+		&complexity.FileStat{
+			Path: "pkg/a.go",
+			Functions: []complexity.FunctionStat{
+				{Name: "covered", Line: 1, Length: 2, Compexity: 5},
+				{Name: "uncovered", Line: 3, Length: 2, Compexity: 5},
+				{Name: "unknown", Line: 10, Length: 1, Compexity: 5},
+			},
+		},
+		&complexity.FileStat{
+			Path:      "pkg/b.go",
+			Functions: []complexity.FunctionStat{{Name: "func", Line: 1, Compexity: 5}},
+		},
+	}
+
+	report := coverage.Report{
+		"github.com/org/repo/pkg/a.go": &coverage.FileCoverage{
+			Path:  "github.com/org/repo/pkg/a.go",
+			Lines: map[uint]uint{1: 1, 2: 3, 3: 0, 4: 0},
+		},
+	}
+
+	AttachCoverage(files, report)
+
+	assert.True(t, files[0].HasCoverage)
+	assert.Equal(t, 0.5, files[0].Coverage)
+
+	fns := files[0].Functions
+	assert.Equal(t, []bool{true, true, false}, []bool{fns[0].HasCoverage, fns[1].HasCoverage, fns[2].HasCoverage})
+	assert.Equal(t, []float64{1, 0, 0}, []float64{fns[0].Coverage, fns[1].Coverage, fns[2].Coverage})
+
+	assert.False(t, files[1].HasCoverage)
+
+	// Coverage of files is kept by filters and passed to plot data
+	files = ApplyFilters(files, ComplexityFilter{MinComplexity: 1}.Filter)
+	Plot = Commits
+	got := PreparePlotData(files, []*complexity.ChurnChunk{{File: "pkg/a.go", Commits: 4}})
+
+	assert.Equal(t, []plot.ScatterEntry{
+		{
+			File:        "pkg/a.go",
+			ScatterData: plot.ScatterData{Complexity: 5, Churn: 4, Coverage: 0.5, HasCoverage: true},
+		},
+	}, got)
+}
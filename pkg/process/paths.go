@@ -0,0 +1,175 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vbvictor/ccv/pkg/complexity"
+)
+
+type PathOptions struct {
+	// Repository root, absolute paths inside it are made relative to it
+	RepoRoot string
+	// Prefixes removed from paths before matching, e.g. build directory
+	StripPrefixes []string
+	// Match paths that differ in leading directories by the longest common suffix
+	SuffixMatch bool
+}
+
+var PathOpts = PathOptions{
+	RepoRoot:      "",
+	StripPrefixes: []string{},
+	SuffixMatch:   false,
+}
+
+// NormalizePath converts path to a clean slash separated path relative to repository root
+func NormalizePath(path string, opts PathOptions) string {
+	if opts.RepoRoot != "" && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(opts.RepoRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+
+	path = filepath.ToSlash(filepath.Clean(path))
+
+	for _, prefix := range opts.StripPrefixes {
+		prefix = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(prefix)), "./")
+		if prefix != "" && strings.HasPrefix(path, prefix+"/") {
+			path = strings.TrimPrefix(path, prefix+"/")
+			break
+		}
+	}
+
+	return path
+}
+
+// NormalizeFiles rewrites paths of files and their functions with NormalizePath
+func NormalizeFiles(files complexity.FilesStat, opts PathOptions) complexity.FilesStat {
+	for _, file := range files {
+		file.Path = NormalizePath(file.Path, opts)
+		for i := range file.Functions {
+			file.Functions[i].File = file.Path
+		}
+	}
+
+	return files
+}
+
+// pathMatcher finds paths of one data source that correspond to paths of another one
+type pathMatcher struct {
+	opts PathOptions
+	// Normalized path to original path
+	paths map[string]string
+	// File name to normalized paths, used for suffix matching
+	byName map[string][]string
+}
+
+func newPathMatcher(paths []string, opts PathOptions) *pathMatcher {
+	m := &pathMatcher{
+		opts:   opts,
+		paths:  make(map[string]string),
+		byName: make(map[string][]string),
+	}
+
+	for _, path := range paths {
+		normalized := NormalizePath(path, opts)
+		m.paths[normalized] = path
+
+		name := filepath.Base(normalized)
+		m.byName[name] = append(m.byName[name], normalized)
+	}
+
+	return m
+}
+
+// match returns original path that corresponds to path, suffix matches must be unambiguous
+func (m *pathMatcher) match(path string) (string, bool) {
+	normalized := NormalizePath(path, m.opts)
+	if original, exists := m.paths[normalized]; exists {
+		return original, true
+	}
+
+	if !m.opts.SuffixMatch {
+		return "", false
+	}
+
+	best, bestLen, ambiguous := "", 0, false
+	for _, candidate := range m.byName[filepath.Base(normalized)] {
+		length := commonSuffixLen(normalized, candidate)
+		switch {
+		case length > bestLen:
+			best, bestLen, ambiguous = candidate, length, false
+		case length == bestLen:
+			ambiguous = true
+		}
+	}
+
+	if best == "" || ambiguous {
+		return "", false
+	}
+
+	return m.paths[best], true
+}
+
+// commonSuffixLen counts trailing path components shared by both paths
+func commonSuffixLen(a, b string) int {
+	partsA, partsB := strings.Split(a, "/"), strings.Split(b, "/")
+
+	length := 0
+	for length < len(partsA) && length < len(partsB) &&
+		partsA[len(partsA)-1-length] == partsB[len(partsB)-1-length] {
+		length++
+	}
+
+	return length
+}
+
+// UnmatchedPaths returns files that have only complexity or only churn data
+func UnmatchedPaths(files complexity.FilesStat, churns []*complexity.ChurnChunk) (complexityOnly, churnOnly []string) {
+	churnPaths := make([]string, 0, len(churns))
+	for _, churn := range churns {
+		churnPaths = append(churnPaths, churn.File)
+	}
+
+	matcher := newPathMatcher(churnPaths, PathOpts)
+	matched := make(map[string]bool)
+
+	complexityOnly = make([]string, 0)
+	for _, file := range files {
+		if path, ok := matcher.match(file.Path); ok {
+			matched[path] = true
+		} else {
+			complexityOnly = append(complexityOnly, file.Path)
+		}
+	}
+
+	churnOnly = make([]string, 0)
+	for _, path := range churnPaths {
+		if !matched[path] {
+			churnOnly = append(churnOnly, path)
+		}
+	}
+
+	sort.Strings(complexityOnly)
+	sort.Strings(churnOnly)
+
+	return complexityOnly, churnOnly
+}
+
+// PrintUnmatched lists files that were dropped from plot data because they have only one metric
+func PrintUnmatched(files complexity.FilesStat, churns []*complexity.ChurnChunk, out io.Writer) {
+	complexityOnly, churnOnly := UnmatchedPaths(files, churns)
+
+	fmt.Fprintf(out, "Files with complexity but without churn (%d):\n", len(complexityOnly))
+	for _, path := range complexityOnly {
+		fmt.Fprintf(out, "  %s\n", path)
+	}
+
+	fmt.Fprintf(out, "Files with churn but without complexity (%d):\n", len(churnOnly))
+	for _, path := range churnOnly {
+		fmt.Fprintf(out, "  %s\n", path)
+	}
+}
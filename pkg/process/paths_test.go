@@ -0,0 +1,60 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vbvictor/ccv/pkg/complexity"
+)
+
+func TestNormalizePath(t *testing.T) {
+	opts := PathOptions{RepoRoot: "/home/user/repo", StripPrefixes: []string{"./build/", "src"}}
+
+	assert.Equal(t, "pkg/a.go", NormalizePath("/home/user/repo/pkg/a.go", opts))
+	assert.Equal(t, "/other/a.go", NormalizePath("/other/a.go", opts))
+	assert.Equal(t, "pkg/a.go", NormalizePath("./pkg/a.go", opts))
+	assert.Equal(t, "a.cpp", NormalizePath("build/a.cpp", opts))
+	assert.Equal(t, "lib/a.cpp", NormalizePath("src/lib/a.cpp", opts))
+	assert.Equal(t, "srcs/a.cpp", NormalizePath("srcs/a.cpp", opts))
+}
+
+func TestPathMatcher(t *testing.T) {
+	churnPaths := []string{"src/core/engine.cpp", "src/main.cpp", "tools/main.cpp", "README.md"}
+
+	exact := newPathMatcher(churnPaths, PathOptions{})
+	_, ok := exact.match("/abs/checkout/src/core/engine.cpp")
+	assert.False(t, ok)
+
+	path, ok := exact.match("./src/main.cpp")
+	assert.True(t, ok)
+	assert.Equal(t, "src/main.cpp", path)
+
+	suffix := newPathMatcher(churnPaths, PathOptions{SuffixMatch: true})
+
+	path, ok = suffix.match("/abs/checkout/src/core/engine.cpp")
+	assert.True(t, ok)
+	assert.Equal(t, "src/core/engine.cpp", path)
+
+	path, ok = suffix.match("/abs/checkout/tools/main.cpp")
+	assert.True(t, ok)
+	assert.Equal(t, "tools/main.cpp", path)
+
+	// Both main.cpp files share only the file name
+	_, ok = suffix.match("other/main.cpp")
+	assert.False(t, ok)
+}
+
+func TestUnmatchedPaths(t *testing.T) {
+	PathOpts = PathOptions{SuffixMatch: true}
+	defer func() { PathOpts = PathOptions{} }()
+
+	files := complexity.FilesStat{
+		{Path: "/ci/work/src/a.cpp"},
+		{Path: "/ci/work/src/generated.cpp"},
+	}
+	churns := []*complexity.ChurnChunk{{File: "src/a.cpp"}, {File: "src/b.cpp"}}
+
+	complexityOnly, churnOnly := UnmatchedPaths(files, churns)
+	assert.Equal(t, []string{"/ci/work/src/generated.cpp"}, complexityOnly)
+	assert.Equal(t, []string{"src/b.cpp"}, churnOnly)
+}
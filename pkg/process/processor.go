@@ -3,19 +3,13 @@ package process
 import (
 	"fmt"
 	"math"
+	"slices"
 
 	"github.com/vbvictor/ccv/pkg/complexity"
 
 	"github.com/vbvictor/ccv/pkg/plot"
 )
 
-type ComplexityInputType int
-
-const (
-	Lizard ComplexityInputType = iota
-	ClangTidy
-)
-
 type ChurnInputType int
 
 const (
@@ -24,19 +18,60 @@ const (
 
 var Version = "0.0.1"
 
-var ComplexityInput ComplexityInputType = Lizard
-
 var ChurnInput ChurnInputType = ModifiedScript
 
 type PlotType = string
 
 const (
-	Commits PlotType = "commits"
-	Changes PlotType = "changes"
+	Commits  PlotType = "commits"
+	Changes  PlotType = "changes"
+	Weighted PlotType = "weighted"
 )
 
+var PlotTypes = []PlotType{Commits, Changes, Weighted}
+
 var Plot = Commits
 
+func ValidatePlotType() error {
+	if !slices.Contains(PlotTypes, Plot) {
+		return fmt.Errorf("invalid plot type %q. Use one of the following: %v", Plot, PlotTypes)
+	}
+
+	return nil
+}
+
+// ValidateChurnData checks that churn read from file has values of Plot type,
+// weighted churn is written only by churn command with half-life
+func ValidateChurnData(report *complexity.ChurnReport) error {
+	if Plot != Weighted || report.HalfLife > 0 {
+		return nil
+	}
+
+	// Files written without metadata still may have weighted churn
+	for _, churn := range report.Files {
+		if churn.WeightedChurn > 0 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("plot type %s requires weighted_changes in churn file, create it with churn --half-life", Weighted)
+}
+
+// ChurnValue returns churn of file used as OY value according to Plot type.
+// Weighted churn keeps its fraction, so recent small changes are not lost.
+func ChurnValue(churn *complexity.ChurnChunk) float64 {
+	switch Plot {
+	case Commits:
+		return float64(churn.Commits)
+	case Changes:
+		return float64(churn.Churn)
+	case Weighted:
+		return churn.WeightedChurn
+	default:
+		panic("Unknown plot type")
+	}
+}
+
 // FilesFilter Place where it is used
 type FilesFilter interface {
 	Filter(files complexity.FilesStat) complexity.FilesStat
@@ -59,8 +94,10 @@ func (f ComplexityFilter) Filter(files complexity.FilesStat) complexity.FilesSta
 
 		if len(filteredFuncs) > 0 {
 			newFile := &complexity.FileStat{
-				Path:      file.Path,
-				Functions: filteredFuncs,
+				Path:        file.Path,
+				Functions:   filteredFuncs,
+				Coverage:    file.Coverage,
+				HasCoverage: file.HasCoverage,
 			}
 			result = append(result, newFile)
 		}
@@ -82,8 +119,10 @@ func ApplyFilters(files complexity.FilesStat, filters ...FilesFilterFunc) comple
 }
 
 type FileComplexity struct {
-	File       string
-	Complexity float64
+	File        string
+	Complexity  float64
+	Coverage    float64
+	HasCoverage bool
 }
 
 // Calculates average complexity bases on functions in file: sum(funcComplexity) / funcCount
@@ -106,8 +145,10 @@ func avgComplexity(files complexity.FilesStat) []FileComplexity {
 		}
 
 		result = append(result, FileComplexity{
-			File:       file.Path,
-			Complexity: complexity,
+			File:        file.Path,
+			Complexity:  complexity,
+			Coverage:    file.Coverage,
+			HasCoverage: file.HasCoverage,
 		})
 	}
 
@@ -115,39 +156,41 @@ func avgComplexity(files complexity.FilesStat) []FileComplexity {
 }
 
 // Skip file if it is not found in chunk or files, first goes over all churns
-// Matches based on filename
+// Matches based on paths normalized with PathOpts, entries use paths of churns
 func PreparePlotData(files complexity.FilesStat, churns []*complexity.ChurnChunk) []plot.ScatterEntry {
 	result := make([]plot.ScatterEntry, 0)
 
-	// Calculate average complexity for each file
-	fileComplexities := avgComplexity(files)
+	// Calculate complexity of each file from its functions
+	fileComplexities := aggregateComplexity(files, AggregateOpts)
 
 	// Create map for quick churn lookup
 	churnMap := make(map[string]*complexity.ChurnChunk)
+	churnPaths := make([]string, 0, len(churns))
 	for _, churn := range churns {
 		churnMap[churn.File] = churn
+		churnPaths = append(churnPaths, churn.File)
 	}
+	matcher := newPathMatcher(churnPaths, PathOpts)
 
 	// Match files with churns and create chart entries
 	for _, fc := range fileComplexities {
-		churn, exists := churnMap[fc.File]
-
+		path, exists := matcher.match(fc.File)
 		if !exists {
+			if Verbose {
+				fmt.Printf("No churn found for file: %s\n", fc.File)
+			}
 			continue
 		}
+		churn := churnMap[path]
 
 		entry := plot.ScatterEntry{
-			File:        fc.File,
-			ScatterData: plot.ScatterData{Complexity: fc.Complexity, Churn: 0},
-		}
-
-		switch Plot {
-		case Commits:
-			entry.Churn = churn.Commits
-		case Changes:
-			entry.Churn = churn.Churn
-		default:
-			panic("Unknown plot type")
+			File: churn.File,
+			ScatterData: plot.ScatterData{
+				Complexity:  fc.Complexity,
+				Churn:       ChurnValue(churn),
+				Coverage:    fc.Coverage,
+				HasCoverage: fc.HasCoverage,
+			},
 		}
 
 		// Round values to 2 decimal places
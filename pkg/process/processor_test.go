@@ -223,3 +223,42 @@ func TestPreparePlotData(t *testing.T) {
 		ScatterData: plot.ScatterData{Complexity: 30, Churn: 50}, // (20 + 40) / 2
 	})
 }
+
+func TestChurnValue(t *testing.T) {
+	churn := &complexity.ChurnChunk{File: "file1.go", Churn: 100, Commits: 5, WeightedChurn: 41.6}
+
+	defer func() { Plot = Commits }()
+
+	for _, tt := range []struct {
+		plot PlotType
+		want float64
+	}{
+		{plot: Commits, want: 5},
+		{plot: Changes, want: 100},
+		{plot: Weighted, want: 41.6},
+	} {
+		Plot = tt.plot
+		assert.NoError(t, ValidatePlotType())
+		assert.Equal(t, tt.want, ChurnValue(churn), tt.plot)
+	}
+
+	Plot = "lines"
+	assert.Error(t, ValidatePlotType())
+}
+
+func TestValidateChurnData(t *testing.T) {
+	defer func() { Plot = Commits }()
+
+	report := &complexity.ChurnReport{Files: []*complexity.ChurnChunk{{File: "a.go", Churn: 10, Commits: 2}}}
+	assert.NoError(t, ValidateChurnData(report))
+
+	Plot = Weighted
+	assert.ErrorContains(t, ValidateChurnData(report), "weighted_changes")
+
+	// Weighted churn of old changes is rounded to zero and omitted
+	report.HalfLife = 30
+	assert.NoError(t, ValidateChurnData(report))
+
+	report = &complexity.ChurnReport{Files: []*complexity.ChurnChunk{{File: "b.go", Churn: 3, Commits: 1, WeightedChurn: 0.25}}}
+	assert.NoError(t, ValidateChurnData(report))
+}
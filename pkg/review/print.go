@@ -0,0 +1,127 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vbvictor/ccv/pkg/plot"
+)
+
+// OutputType represents the type of output of review subcommand
+type OutputType = string
+
+var (
+	Markdown      OutputType = "markdown"
+	JSON          OutputType = "json"
+	OutputFormats            = []OutputType{Markdown, JSON}
+)
+
+func PrintReport(report *Report, out io.Writer, format OutputType) error {
+	switch format {
+	case Markdown:
+		printMarkdown(report, out)
+	case JSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("Invalid output format. Use one of the following: %v", OutputFormats)
+	}
+
+	return nil
+}
+
+func printMarkdown(report *Report, out io.Writer) {
+	highRisk, addsComplexity := 0, 0
+	for _, file := range report.Files {
+		if file.HighRisk {
+			highRisk++
+		}
+		if file.AddsComplexity {
+			addsComplexity++
+		}
+	}
+
+	fmt.Fprintf(out, "## Complexity and churn review of %s..%s\n\n", shortHash(report.Base), shortHash(report.Head))
+	fmt.Fprintf(out, "Changed files: %d, touching high-risk code: %d, adding complexity: %d\n\n",
+		len(report.Files), highRisk, addsComplexity)
+
+	if len(report.Files) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "| File | Status | Lines | Complexity | Churn | Risk | Risk level | Flags |")
+	fmt.Fprintln(out, "|---|---|---:|---:|---:|---:|---|---|")
+	for _, file := range report.Files {
+		fmt.Fprintf(out, "| `%s` | %s | +%d/-%d | %s | %s | %.2f | %s | %s |\n",
+			file.Path,
+			file.Status,
+			file.Added,
+			file.Removed,
+			formatDelta(file.Complexity, file.OldComplexity, file.Status == "added"),
+			plot.FormatChurn(file.Churn),
+			file.Risk,
+			file.RiskLevel,
+			flags(file.HighRisk, file.AddsComplexity))
+	}
+
+	fmt.Fprintln(out)
+
+	functions := 0
+	for _, file := range report.Files {
+		functions += len(file.Functions)
+	}
+	if functions == 0 {
+		return
+	}
+
+	fmt.Fprintf(out, "### Changed functions (%d)\n\n", functions)
+	fmt.Fprintln(out, "| Function | File | Complexity | Churn | Risk | Risk level | Flags |")
+	fmt.Fprintln(out, "|---|---|---:|---:|---:|---|---|")
+	for _, file := range report.Files {
+		for _, fn := range file.Functions {
+			fmt.Fprintf(out, "| `%s` | `%s:%d` | %s | %s | %.2f | %s | %s |\n",
+				fn.Name,
+				file.Path,
+				fn.Line,
+				formatDelta(float64(fn.Complexity), float64(fn.OldComplexity), fn.New),
+				plot.FormatChurn(fn.Churn),
+				fn.Risk,
+				fn.RiskLevel,
+				flags(fn.HighRisk, fn.AddsComplexity))
+		}
+	}
+
+	fmt.Fprintln(out)
+}
+
+// formatDelta prints current value with change relative to old one
+func formatDelta(value, old float64, isNew bool) string {
+	if isNew {
+		return fmt.Sprintf("%.2f (new)", value)
+	}
+	if value == old {
+		return fmt.Sprintf("%.2f", value)
+	}
+	return fmt.Sprintf("%.2f (%+.2f)", value, value-old)
+}
+
+func flags(highRisk, addsComplexity bool) string {
+	result := make([]string, 0, 2)
+	if highRisk {
+		result = append(result, "**high risk**")
+	}
+	if addsComplexity {
+		result = append(result, "**adds complexity**")
+	}
+	return strings.Join(result, ", ")
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
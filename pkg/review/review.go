@@ -0,0 +1,252 @@
+package review
+
+import (
+	"math"
+	"sort"
+
+	"github.com/vbvictor/ccv/pkg/complexity"
+	"github.com/vbvictor/ccv/pkg/git"
+	"github.com/vbvictor/ccv/pkg/plot"
+	"github.com/vbvictor/ccv/pkg/process"
+	"github.com/vbvictor/ccv/pkg/snapshot"
+)
+
+type Options struct {
+	// Files and functions with risk score not less than threshold are high-risk
+	HotspotThreshold float64
+	// Complexity must rise by more than delta to be flagged
+	ComplexityDelta float64
+}
+
+var Opts = Options{
+	HotspotThreshold: 0,
+	ComplexityDelta:  0,
+}
+
+// Input is data of revision range, paths of complexity are relative to repository root
+type Input struct {
+	Base, Head    string
+	Changes       []git.FileDiff
+	BaseFiles     complexity.FilesStat
+	HeadFiles     complexity.FilesStat
+	Churns        []*complexity.ChurnChunk    // History before base
+	FunctionChurn []*complexity.FunctionChurn // History before base, paths at base
+}
+
+type FileChange struct {
+	Path           string           `json:"path"`
+	OldPath        string           `json:"old_path,omitempty"`
+	Status         string           `json:"status"`
+	Added          uint             `json:"added"`
+	Removed        uint             `json:"removed"`
+	Complexity     float64          `json:"complexity"`
+	OldComplexity  float64          `json:"old_complexity"`
+	Churn          float64          `json:"churn"`
+	Risk           float64          `json:"risk"`
+	RiskLevel      string           `json:"risk_level"`
+	HighRisk       bool             `json:"high_risk"`
+	AddsComplexity bool             `json:"adds_complexity"`
+	Functions      []FunctionChange `json:"functions"`
+}
+
+type FunctionChange struct {
+	Name           string  `json:"name"`
+	Line           uint    `json:"line"`
+	New            bool    `json:"new"`
+	Complexity     uint    `json:"complexity"`
+	OldComplexity  uint    `json:"old_complexity"`
+	Churn          float64 `json:"churn"`
+	Risk           float64 `json:"risk"`
+	RiskLevel      string  `json:"risk_level"`
+	HighRisk       bool    `json:"high_risk"`
+	AddsComplexity bool    `json:"adds_complexity"`
+}
+
+type Report struct {
+	Base  string       `json:"base"`
+	Head  string       `json:"head"`
+	Files []FileChange `json:"files"`
+}
+
+// Build joins changed files and functions with their complexity and historical churn.
+// Churn metric and complexity aggregation follow process.Plot and process.AggregateOpts.
+func Build(input Input, opts Options) (*Report, error) {
+	headFiles := indexFiles(input.HeadFiles)
+	baseFiles := indexFiles(input.BaseFiles)
+	headComplexity := indexComplexity(input.HeadFiles)
+	baseComplexity := indexComplexity(input.BaseFiles)
+
+	churns := make(map[string]*complexity.ChurnChunk, len(input.Churns))
+	for _, churn := range input.Churns {
+		churns[churn.File] = churn
+	}
+
+	functionChurns := make(map[string]*complexity.FunctionChurn, len(input.FunctionChurn))
+	for _, fn := range input.FunctionChurn {
+		functionChurns[functionKey(fn.File, snapshot.FunctionName(fn.Package, fn.Name))] = fn
+	}
+
+	files := make([]FileChange, 0, len(input.Changes))
+	fileData := make([]plot.ScatterEntry, 0, len(input.Changes))
+	functionData := make([]plot.ScatterEntry, 0)
+
+	for _, change := range input.Changes {
+		file := FileChange{
+			Path:          change.Path,
+			OldPath:       change.OldPath,
+			Status:        change.Status(),
+			Added:         change.Added,
+			Removed:       change.Removed,
+			Complexity:    headComplexity[change.Path],
+			OldComplexity: baseComplexity[change.OldPath],
+			Functions:     make([]FunctionChange, 0),
+		}
+		if file.Path == "" {
+			file.Path = change.OldPath
+		}
+		if file.OldPath == file.Path {
+			file.OldPath = ""
+		}
+
+		if churn, exists := churns[change.OldPath]; exists {
+			file.Churn = process.ChurnValue(churn)
+		}
+
+		for _, fn := range changedFunctions(headFiles[change.Path], change.NewRanges) {
+			name := snapshot.FunctionName(fn.Package, fn.Name)
+			function := FunctionChange{
+				Name:       name,
+				Line:       fn.Line,
+				Complexity: fn.Compexity,
+				New:        true,
+			}
+
+			if old, exists := findFunction(baseFiles[change.OldPath], name); exists {
+				function.New = false
+				function.OldComplexity = old.Compexity
+			}
+
+			if churn, exists := functionChurns[functionKey(change.OldPath, name)]; exists {
+				function.Churn = process.ChurnValue(&churn.ChurnChunk)
+			}
+
+			file.Functions = append(file.Functions, function)
+			functionData = append(functionData, plot.ScatterEntry{
+				File:        file.Path,
+				ScatterData: plot.ScatterData{Complexity: float64(function.Complexity), Churn: function.Churn},
+			})
+		}
+
+		files = append(files, file)
+		fileData = append(fileData, plot.ScatterEntry{
+			File:        file.Path,
+			ScatterData: plot.ScatterData{Complexity: file.Complexity, Churn: file.Churn},
+		})
+	}
+
+	fileScorer, err := plot.NewRiskScorer(plot.RiskOpts, fileData)
+	if err != nil {
+		return nil, err
+	}
+
+	functionScorer, err := plot.NewRiskScorer(plot.RiskOpts, functionData)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := 0
+	for i := range files {
+		file := &files[i]
+		file.Risk = roundScore(fileScorer.Score(fileData[i].ScatterData))
+		file.RiskLevel = plot.RiskLevelOf(file.Risk)
+		file.HighRisk = file.Status != "deleted" && file.Risk >= opts.HotspotThreshold
+		// Complexity of added files is judged by their functions
+		file.AddsComplexity = file.Status != "added" && file.Status != "deleted" &&
+			file.Complexity-file.OldComplexity > opts.ComplexityDelta
+
+		for j := range file.Functions {
+			fn := &file.Functions[j]
+			fn.Risk = roundScore(functionScorer.Score(functionData[idx].ScatterData))
+			fn.RiskLevel = plot.RiskLevelOf(fn.Risk)
+			fn.HighRisk = fn.Risk >= opts.HotspotThreshold
+			fn.AddsComplexity = complexityDelta(*fn) > opts.ComplexityDelta
+			idx++
+
+			file.HighRisk = file.HighRisk || fn.HighRisk
+			file.AddsComplexity = file.AddsComplexity || fn.AddsComplexity
+		}
+
+		sort.SliceStable(file.Functions, func(a, b int) bool { return file.Functions[a].Risk > file.Functions[b].Risk })
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Risk > files[j].Risk })
+
+	return &Report{Base: input.Base, Head: input.Head, Files: files}, nil
+}
+
+// complexityDelta of new function is counted from 1, so new straight-line functions add nothing
+func complexityDelta(fn FunctionChange) float64 {
+	if fn.New {
+		return float64(fn.Complexity) - 1
+	}
+	return float64(fn.Complexity) - float64(fn.OldComplexity)
+}
+
+// changedFunctions returns functions which lines intersect changed ranges of new version of file
+func changedFunctions(file *complexity.FileStat, ranges []git.LineRange) []complexity.FunctionStat {
+	result := make([]complexity.FunctionStat, 0)
+	if file == nil {
+		return result
+	}
+
+	for _, fn := range file.Functions {
+		start, end := fn.Line, fn.Line+max(fn.Length, 1)
+
+		for _, r := range ranges {
+			if r.Overlaps(start, end) {
+				result = append(result, fn)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+func findFunction(file *complexity.FileStat, name string) (complexity.FunctionStat, bool) {
+	if file == nil {
+		return complexity.FunctionStat{}, false
+	}
+
+	for _, fn := range file.Functions {
+		if snapshot.FunctionName(fn.Package, fn.Name) == name {
+			return fn, true
+		}
+	}
+
+	return complexity.FunctionStat{}, false
+}
+
+func indexFiles(files complexity.FilesStat) map[string]*complexity.FileStat {
+	result := make(map[string]*complexity.FileStat, len(files))
+	for _, file := range files {
+		result[file.Path] = file
+	}
+	return result
+}
+
+func indexComplexity(files complexity.FilesStat) map[string]float64 {
+	result := make(map[string]float64, len(files))
+	for _, fc := range process.AggregateComplexity(files) {
+		result[fc.File] = math.Round(fc.Complexity*100) / 100
+	}
+	return result
+}
+
+func functionKey(path, name string) string {
+	return path + ":" + name
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
@@ -0,0 +1,280 @@
+package review
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbvictor/ccv/pkg/complexity"
+	"github.com/vbvictor/ccv/pkg/git"
+	"github.com/vbvictor/ccv/pkg/process"
+)
+
+// setPlot sets churn metric of process package for the test
+func setPlot(t *testing.T, metric process.PlotType) {
+	t.Helper()
+
+	prev := process.Plot
+	process.Plot = metric
+	t.Cleanup(func() { process.Plot = prev })
+}
+
+func TestBuild(t *testing.T) {
+	const lowRisk = "Very Low Risk"
+
+	handle := func(line, length, cc uint) complexity.FunctionStat {
+		return complexity.FunctionStat{Package: []string{"Server"}, Name: "Handle", Line: line, Length: length, Compexity: cc}
+	}
+	file := func(path string, functions ...complexity.FunctionStat) *complexity.FileStat {
+		return &complexity.FileStat{Path: path, Functions: functions}
+	}
+	untouched := func(line uint) complexity.FunctionStat {
+		return complexity.FunctionStat{Name: "untouched", Line: line, Length: 5, Compexity: 2}
+	}
+	handleChurn := func(path string) []*complexity.FunctionChurn {
+		return []*complexity.FunctionChurn{
+			{ChurnChunk: complexity.ChurnChunk{File: path, Churn: 20, Commits: 4}, Package: []string{"Server"}, Name: "Handle"},
+		}
+	}
+	modified := git.FileDiff{
+		Path: "server.go", OldPath: "server.go", Added: 2, Removed: 1,
+		NewRanges: []git.LineRange{{Start: 12, Lines: 2}},
+	}
+	serverInput := Input{
+		Changes:       []git.FileDiff{modified},
+		BaseFiles:     complexity.FilesStat{file("server.go", handle(10, 10, 6), untouched(30))},
+		HeadFiles:     complexity.FilesStat{file("server.go", handle(10, 11, 8), untouched(31))},
+		Churns:        []*complexity.ChurnChunk{{File: "server.go", Churn: 40, Commits: 10}},
+		FunctionChurn: handleChurn("server.go"),
+	}
+	serverHandle := FunctionChange{
+		Name: "Server::Handle", Line: 10, Complexity: 8, OldComplexity: 6, Churn: 4,
+		Risk: 32, RiskLevel: lowRisk, AddsComplexity: true,
+	}
+	serverChange := FileChange{
+		Path: "server.go", Status: "modified", Added: 2, Removed: 1,
+		Complexity: 5, OldComplexity: 4, Churn: 10, Risk: 50, RiskLevel: lowRisk,
+		HighRisk: true, AddsComplexity: true,
+		Functions: []FunctionChange{serverHandle},
+	}
+
+	tests := []struct {
+		name     string
+		input    Input
+		opts     Options
+		expected []FileChange
+	}{
+		{
+			name:     "no changes",
+			input:    Input{},
+			opts:     Options{HotspotThreshold: 35},
+			expected: []FileChange{},
+		},
+		{
+			// Function outside of changed lines is not listed
+			name:     "modified file",
+			input:    serverInput,
+			opts:     Options{HotspotThreshold: 35},
+			expected: []FileChange{serverChange},
+		},
+		{
+			name:  "risk exactly at threshold",
+			input: serverInput,
+			opts:  Options{HotspotThreshold: 32},
+			expected: []FileChange{func() FileChange {
+				change := serverChange
+				change.Functions = []FunctionChange{serverHandle}
+				change.Functions[0].HighRisk = true
+				return change
+			}()},
+		},
+		{
+			name:  "complexity rise exactly at delta",
+			input: serverInput,
+			opts:  Options{HotspotThreshold: 100, ComplexityDelta: 2},
+			expected: []FileChange{func() FileChange {
+				change := serverChange
+				change.HighRisk = false
+				change.AddsComplexity = false
+				change.Functions = []FunctionChange{serverHandle}
+				change.Functions[0].AddsComplexity = false
+				return change
+			}()},
+		},
+		{
+			// Range without lines is a point of deleted lines inside of function
+			name: "empty range",
+			input: Input{
+				Changes: []git.FileDiff{{
+					Path: "server.go", OldPath: "server.go", Removed: 3,
+					OldRanges: []git.LineRange{{Start: 15, Lines: 3}},
+					NewRanges: []git.LineRange{{Start: 14, Lines: 0}},
+				}},
+				BaseFiles: complexity.FilesStat{file("server.go", handle(10, 14, 9))},
+				HeadFiles: complexity.FilesStat{file("server.go", handle(10, 11, 6))},
+			},
+			opts: Options{HotspotThreshold: 35},
+			expected: []FileChange{{
+				Path: "server.go", Status: "modified", Removed: 3,
+				Complexity: 6, OldComplexity: 9, RiskLevel: lowRisk,
+				Functions: []FunctionChange{{Name: "Server::Handle", Line: 10, Complexity: 6, OldComplexity: 9, RiskLevel: lowRisk}},
+			}},
+		},
+		{
+			name: "change outside of functions",
+			input: Input{
+				Changes:   []git.FileDiff{{Path: "server.go", OldPath: "server.go", Added: 1, NewRanges: []git.LineRange{{Start: 1, Lines: 1}}}},
+				BaseFiles: complexity.FilesStat{file("server.go", handle(10, 11, 8))},
+				HeadFiles: complexity.FilesStat{file("server.go", handle(11, 11, 8))},
+				Churns:    []*complexity.ChurnChunk{{File: "server.go", Churn: 40, Commits: 10}},
+			},
+			opts: Options{HotspotThreshold: 35},
+			expected: []FileChange{{
+				Path: "server.go", Status: "modified", Added: 1,
+				Complexity: 8, OldComplexity: 8, Churn: 10, Risk: 80, RiskLevel: lowRisk, HighRisk: true,
+				Functions: []FunctionChange{},
+			}},
+		},
+		{
+			// New function is counted from complexity 1, so straight-line code adds nothing
+			name: "added straight-line function",
+			input: Input{
+				Changes:   []git.FileDiff{{Path: "util.go", Added: 5, NewRanges: []git.LineRange{{Start: 1, Lines: 5}}}},
+				HeadFiles: complexity.FilesStat{file("util.go", complexity.FunctionStat{Name: "getter", Line: 2, Length: 3, Compexity: 1})},
+			},
+			opts: Options{HotspotThreshold: 35},
+			expected: []FileChange{{
+				Path: "util.go", Status: "added", Added: 5, Complexity: 1, RiskLevel: lowRisk,
+				Functions: []FunctionChange{{Name: "getter", Line: 2, New: true, Complexity: 1, RiskLevel: lowRisk}},
+			}},
+		},
+		{
+			name: "added branching function",
+			input: Input{
+				Changes:   []git.FileDiff{{Path: "util.go", Added: 5, NewRanges: []git.LineRange{{Start: 1, Lines: 5}}}},
+				HeadFiles: complexity.FilesStat{file("util.go", complexity.FunctionStat{Name: "parse", Line: 2, Length: 3, Compexity: 3})},
+			},
+			opts: Options{HotspotThreshold: 35},
+			expected: []FileChange{{
+				Path: "util.go", Status: "added", Added: 5, Complexity: 3, RiskLevel: lowRisk, AddsComplexity: true,
+				Functions: []FunctionChange{{Name: "parse", Line: 2, New: true, Complexity: 3, RiskLevel: lowRisk, AddsComplexity: true}},
+			}},
+		},
+		{
+			// Deleted files have no risk, even with zero threshold
+			name: "deleted file",
+			input: Input{
+				Changes:   []git.FileDiff{{OldPath: "server.go", Removed: 20, OldRanges: []git.LineRange{{Start: 1, Lines: 20}}}},
+				BaseFiles: complexity.FilesStat{file("server.go", handle(10, 10, 6))},
+				Churns:    []*complexity.ChurnChunk{{File: "server.go", Churn: 40, Commits: 10}},
+			},
+			opts: Options{HotspotThreshold: 0},
+			expected: []FileChange{{
+				Path: "server.go", Status: "deleted", Removed: 20, OldComplexity: 6, Churn: 10, RiskLevel: lowRisk,
+				Functions: []FunctionChange{},
+			}},
+		},
+		{
+			// Churn and old complexity are taken by name of file at base
+			name: "renamed file",
+			input: Input{
+				Changes: []git.FileDiff{{
+					Path: "server.go", OldPath: "old/server.go", Added: 2, Removed: 1,
+					NewRanges: []git.LineRange{{Start: 12, Lines: 2}},
+				}},
+				BaseFiles:     complexity.FilesStat{file("old/server.go", handle(10, 10, 6))},
+				HeadFiles:     complexity.FilesStat{file("server.go", handle(10, 11, 8))},
+				Churns:        []*complexity.ChurnChunk{{File: "old/server.go", Churn: 40, Commits: 10}},
+				FunctionChurn: handleChurn("old/server.go"),
+			},
+			opts: Options{HotspotThreshold: 35},
+			expected: []FileChange{{
+				Path: "server.go", OldPath: "old/server.go", Status: "renamed", Added: 2, Removed: 1,
+				Complexity: 8, OldComplexity: 6, Churn: 10, Risk: 80, RiskLevel: lowRisk,
+				HighRisk: true, AddsComplexity: true,
+				Functions: []FunctionChange{serverHandle},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setPlot(t, process.Commits)
+
+			tt.input.Base = "1111111111111111111111111111111111111111"
+			tt.input.Head = "2222222222222222222222222222222222222222"
+
+			report, err := Build(tt.input, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.input.Base, report.Base)
+			assert.Equal(t, tt.input.Head, report.Head)
+			assert.Equal(t, tt.expected, report.Files)
+		})
+	}
+}
+
+func TestPrintMarkdown(t *testing.T) {
+	setPlot(t, process.Commits)
+
+	input := Input{
+		Base: "1111111111111111111111111111111111111111",
+		Head: "2222222222222222222222222222222222222222",
+		Changes: []git.FileDiff{
+			{
+				Path: "server.go", OldPath: "server.go", Added: 2, Removed: 1,
+				NewRanges: []git.LineRange{{Start: 12, Lines: 2}},
+			},
+			{
+				Path: "util.go", Added: 5,
+				NewRanges: []git.LineRange{{Start: 1, Lines: 5}},
+			},
+		},
+		BaseFiles: complexity.FilesStat{
+			{
+				Path: "server.go",
+				Functions: []complexity.FunctionStat{
+					{Package: []string{"Server"}, Name: "Handle", Line: 10, Length: 10, Compexity: 6},
+					{Name: "untouched", Line: 30, Length: 5, Compexity: 2},
+				},
+			},
+		},
+		HeadFiles: complexity.FilesStat{
+			{
+				Path: "server.go",
+				Functions: []complexity.FunctionStat{
+					{Package: []string{"Server"}, Name: "Handle", Line: 10, Length: 11, Compexity: 8},
+					{Name: "untouched", Line: 31, Length: 5, Compexity: 2},
+				},
+			},
+			{
+				Path:      "util.go",
+				Functions: []complexity.FunctionStat{{Name: "getter", Line: 2, Length: 3, Compexity: 1}},
+			},
+		},
+		Churns: []*complexity.ChurnChunk{{File: "server.go", Churn: 40, Commits: 10}},
+		FunctionChurn: []*complexity.FunctionChurn{
+			{ChurnChunk: complexity.ChurnChunk{File: "server.go", Churn: 20, Commits: 4}, Package: []string{"Server"}, Name: "Handle"},
+		},
+	}
+
+	report, err := Build(input, Options{HotspotThreshold: 35})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, PrintReport(report, &buf, Markdown))
+
+	expected := "## Complexity and churn review of 111111111111..222222222222\n\n" +
+		"Changed files: 2, touching high-risk code: 1, adding complexity: 1\n\n" +
+		"| File | Status | Lines | Complexity | Churn | Risk | Risk level | Flags |\n" +
+		"|---|---|---:|---:|---:|---:|---|---|\n" +
+		"| `server.go` | modified | +2/-1 | 5.00 (+1.00) | 10 | 50.00 | Very Low Risk | **high risk**, **adds complexity** |\n" +
+		"| `util.go` | added | +5/-0 | 1.00 (new) | 0 | 0.00 | Very Low Risk |  |\n\n" +
+		"### Changed functions (2)\n\n" +
+		"| Function | File | Complexity | Churn | Risk | Risk level | Flags |\n" +
+		"|---|---|---:|---:|---:|---|---|\n" +
+		"| `Server::Handle` | `server.go:10` | 8.00 (+2.00) | 4 | 32.00 | Very Low Risk | **adds complexity** |\n" +
+		"| `getter` | `util.go:2` | 1.00 (new) | 0 | 0.00 | Very Low Risk |  |\n\n"
+
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,166 @@
+package snapshot
+
+import (
+	"sort"
+	"time"
+)
+
+// Metric names used in regressions
+const (
+	MetricComplexity = "complexity"
+	MetricChurn      = "churn"
+	MetricRisk       = "risk"
+)
+
+type CompareOptions struct {
+	// Metrics must rise by more than delta to be reported
+	ComplexityDelta float64
+	ChurnDelta      float64
+	RiskDelta       float64
+	// Files with risk score not less than threshold are hotspots
+	HotspotThreshold float64
+}
+
+var CompareOpts = CompareOptions{
+	ComplexityDelta:  0,
+	ChurnDelta:       10,
+	RiskDelta:        10,
+	HotspotThreshold: 0,
+}
+
+// Regression is a metric of file or function that rose between snapshots
+type Regression struct {
+	Path     string  `json:"path"`
+	Function string  `json:"function,omitempty"`
+	Metric   string  `json:"metric"`
+	Old      float64 `json:"old"`
+	New      float64 `json:"new"`
+	Delta    float64 `json:"delta"`
+}
+
+type Hotspot struct {
+	Path      string  `json:"path"`
+	Risk      float64 `json:"risk"`
+	RiskLevel string  `json:"risk_level"`
+}
+
+type SnapshotInfo struct {
+	CreatedAt time.Time `json:"created_at"`
+	Commit    string    `json:"commit,omitempty"`
+}
+
+type Diff struct {
+	Old           SnapshotInfo `json:"old"`
+	New           SnapshotInfo `json:"new"`
+	Regressions   []Regression `json:"regressions"`
+	NewHotspots   []Hotspot    `json:"new_hotspots"`
+	FixedHotspots []Hotspot    `json:"fixed_hotspots"`
+}
+
+// Compare reports rises of metrics of entries present in both snapshots,
+// hotspots that appeared in head snapshot and hotspots of base snapshot that are gone
+func Compare(base, head *Snapshot, opts CompareOptions) *Diff {
+	diff := &Diff{
+		Old:           SnapshotInfo{CreatedAt: base.CreatedAt, Commit: base.Commit},
+		New:           SnapshotInfo{CreatedAt: head.CreatedAt, Commit: head.Commit},
+		Regressions:   make([]Regression, 0),
+		NewHotspots:   make([]Hotspot, 0),
+		FixedHotspots: make([]Hotspot, 0),
+	}
+
+	baseFiles := make(map[string]FileEntry, len(base.Files))
+	for _, file := range base.Files {
+		baseFiles[file.Path] = file
+	}
+
+	headFiles := make(map[string]FileEntry, len(head.Files))
+	for _, file := range head.Files {
+		headFiles[file.Path] = file
+
+		prev, exists := baseFiles[file.Path]
+		if !exists {
+			if file.Risk >= opts.HotspotThreshold {
+				diff.NewHotspots = append(diff.NewHotspots, hotspotOf(file))
+			}
+			continue
+		}
+
+		diff.addRegressions(file.Path, "", opts,
+			[3]float64{prev.Complexity, prev.Churn, prev.Risk},
+			[3]float64{file.Complexity, file.Churn, file.Risk})
+
+		if file.Risk >= opts.HotspotThreshold && prev.Risk < opts.HotspotThreshold {
+			diff.NewHotspots = append(diff.NewHotspots, hotspotOf(file))
+		}
+		if file.Risk < opts.HotspotThreshold && prev.Risk >= opts.HotspotThreshold {
+			diff.FixedHotspots = append(diff.FixedHotspots, hotspotOf(file))
+		}
+	}
+
+	for _, file := range base.Files {
+		if _, exists := headFiles[file.Path]; !exists && file.Risk >= opts.HotspotThreshold {
+			diff.FixedHotspots = append(diff.FixedHotspots, hotspotOf(file))
+		}
+	}
+
+	baseFunctions := make(map[string]FunctionEntry, len(base.Functions))
+	for _, fn := range base.Functions {
+		if _, exists := baseFunctions[fn.key()]; !exists {
+			baseFunctions[fn.key()] = fn
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, fn := range head.Functions {
+		prev, exists := baseFunctions[fn.key()]
+		if !exists || seen[fn.key()] {
+			continue
+		}
+		seen[fn.key()] = true
+
+		diff.addRegressions(fn.Path, fn.Name, opts,
+			[3]float64{float64(prev.Complexity), prev.Churn, prev.Risk},
+			[3]float64{float64(fn.Complexity), fn.Churn, fn.Risk})
+	}
+
+	sort.SliceStable(diff.Regressions, func(i, j int) bool {
+		return diff.Regressions[i].Delta > diff.Regressions[j].Delta
+	})
+	sortHotspots(diff.NewHotspots)
+	sortHotspots(diff.FixedHotspots)
+
+	return diff
+}
+
+// addRegressions compares complexity, churn and risk of entry
+func (d *Diff) addRegressions(path, function string, opts CompareOptions, base, head [3]float64) {
+	metrics := []struct {
+		name  string
+		delta float64
+	}{
+		{name: MetricComplexity, delta: opts.ComplexityDelta},
+		{name: MetricChurn, delta: opts.ChurnDelta},
+		{name: MetricRisk, delta: opts.RiskDelta},
+	}
+
+	for i, metric := range metrics {
+		if delta := head[i] - base[i]; delta > metric.delta {
+			d.Regressions = append(d.Regressions, Regression{
+				Path:     path,
+				Function: function,
+				Metric:   metric.name,
+				Old:      base[i],
+				New:      head[i],
+				Delta:    delta,
+			})
+		}
+	}
+}
+
+func hotspotOf(file FileEntry) Hotspot {
+	return Hotspot{Path: file.Path, Risk: file.Risk, RiskLevel: file.RiskLevel}
+}
+
+func sortHotspots(hotspots []Hotspot) {
+	sort.Slice(hotspots, func(i, j int) bool { return hotspots[i].Risk > hotspots[j].Risk })
+}
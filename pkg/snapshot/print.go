@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// OutputType represents the type of output of diff subcommand
+type OutputType = string
+
+var (
+	JSON          OutputType = "json"
+	Tabular       OutputType = "tabular"
+	Markdown      OutputType = "markdown"
+	OutputFormats            = []OutputType{Tabular, JSON, Markdown}
+)
+
+func PrintDiff(diff *Diff, out io.Writer, format OutputType) error {
+	switch format {
+	case Tabular:
+		printDiffTable(diff, out)
+	case JSON:
+		return printDiffJSON(diff, out)
+	case Markdown:
+		printDiffMarkdown(diff, out)
+	default:
+		return fmt.Errorf("Invalid output format. Use one of the following: %v", OutputFormats)
+	}
+
+	return nil
+}
+
+func printDiffTable(diff *Diff, out io.Writer) {
+	fmt.Fprintf(out, "\nRegressions (%d):\n", len(diff.Regressions))
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+	fmt.Fprintf(out, "%-12s %-10s %-10s %-10s %s\n", "METRIC", "OLD", "NEW", "DELTA", "LOCATION")
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+
+	for _, r := range diff.Regressions {
+		fmt.Fprintf(out, "%-12s %-10.2f %-10.2f %-+10.2f %s\n", r.Metric, r.Old, r.New, r.Delta, location(r))
+	}
+
+	printHotspotsTable("New hotspots", diff.NewHotspots, out)
+	printHotspotsTable("Fixed hotspots", diff.FixedHotspots, out)
+}
+
+func printHotspotsTable(title string, hotspots []Hotspot, out io.Writer) {
+	fmt.Fprintf(out, "\n%s (%d):\n", title, len(hotspots))
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+	fmt.Fprintf(out, "%-12s %-16s %s\n", "RISK SCORE", "RISK LEVEL", "FILEPATH")
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+
+	for _, h := range hotspots {
+		fmt.Fprintf(out, "%-12.2f %-16s %s\n", h.Risk, h.RiskLevel, h.Path)
+	}
+}
+
+func printDiffJSON(diff *Diff, out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(diff)
+}
+
+func printDiffMarkdown(diff *Diff, out io.Writer) {
+	fmt.Fprintf(out, "## Complexity and churn changes\n\n")
+
+	fmt.Fprintf(out, "### Regressions (%d)\n\n", len(diff.Regressions))
+	if len(diff.Regressions) > 0 {
+		fmt.Fprintln(out, "| Metric | Old | New | Delta | Location |")
+		fmt.Fprintln(out, "|---|---:|---:|---:|---|")
+		for _, r := range diff.Regressions {
+			fmt.Fprintf(out, "| %s | %.2f | %.2f | %+.2f | `%s` |\n", r.Metric, r.Old, r.New, r.Delta, location(r))
+		}
+		fmt.Fprintln(out)
+	}
+
+	printHotspotsMarkdown("New hotspots", diff.NewHotspots, out)
+	printHotspotsMarkdown("Fixed hotspots", diff.FixedHotspots, out)
+}
+
+func printHotspotsMarkdown(title string, hotspots []Hotspot, out io.Writer) {
+	fmt.Fprintf(out, "### %s (%d)\n\n", title, len(hotspots))
+	if len(hotspots) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "| Risk score | Risk level | File |")
+	fmt.Fprintln(out, "|---:|---|---|")
+	for _, h := range hotspots {
+		fmt.Fprintf(out, "| %.2f | %s | `%s` |\n", h.Risk, h.RiskLevel, h.Path)
+	}
+	fmt.Fprintln(out)
+}
+
+func location(r Regression) string {
+	if r.Function == "" {
+		return r.Path
+	}
+	return r.Path + " " + r.Function
+}
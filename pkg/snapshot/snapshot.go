@@ -0,0 +1,172 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vbvictor/ccv/pkg/plot"
+)
+
+// Version of snapshot format, incremented on incompatible changes.
+// Version 2 adds risk settings, snapshots of version 1 are still read.
+const Version = 2
+
+// Snapshot is joined churn, complexity and risk dataset of repository at some moment
+type Snapshot struct {
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	Repository string    `json:"repository,omitempty"`
+	Commit     string    `json:"commit,omitempty"`
+	// Description of risk score formula, scores of different formulas are not comparable
+	RiskFormula string `json:"risk_formula"`
+	// Options and levels risk scores are computed and categorized with, missing in version 1
+	Risk      *RiskSettings   `json:"risk,omitempty"`
+	Files     []FileEntry     `json:"files"`
+	Functions []FunctionEntry `json:"functions"`
+}
+
+// RiskSettings keep risk options and levels of snapshot, so scores are categorized the same way they are computed
+type RiskSettings struct {
+	Formula          string      `json:"formula"`
+	Expression       string      `json:"expression,omitempty"`
+	Normalization    string      `json:"normalization"`
+	ComplexityWeight float64     `json:"complexity_weight"`
+	ChurnWeight      float64     `json:"churn_weight"`
+	Levels           []RiskLevel `json:"levels"`
+}
+
+// RiskLevel is a risk level in JSON, missing max means level has no upper bound
+type RiskLevel struct {
+	Name  string   `json:"name"`
+	Color string   `json:"color"`
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+func NewRiskSettings(opts plot.RiskOptions, levels []plot.RiskLevel) *RiskSettings {
+	settings := &RiskSettings{
+		Formula:          opts.Formula,
+		Expression:       opts.Expression,
+		Normalization:    opts.Normalization,
+		ComplexityWeight: opts.ComplexityWeight,
+		ChurnWeight:      opts.ChurnWeight,
+		Levels:           make([]RiskLevel, 0, len(levels)),
+	}
+
+	for _, level := range levels {
+		saved := RiskLevel{Name: level.Name, Color: level.Color, Min: level.Min}
+		if !math.IsInf(level.Max, 1) {
+			saved.Max = &level.Max
+		}
+		settings.Levels = append(settings.Levels, saved)
+	}
+
+	return settings
+}
+
+// Levels returns risk levels of snapshot, fallback is returned for snapshots without risk settings
+func (s *Snapshot) Levels(fallback []plot.RiskLevel) []plot.RiskLevel {
+	if s.Risk == nil || len(s.Risk.Levels) == 0 {
+		return fallback
+	}
+
+	levels := make([]plot.RiskLevel, 0, len(s.Risk.Levels))
+	for _, level := range s.Risk.Levels {
+		max := math.Inf(1)
+		if level.Max != nil {
+			max = *level.Max
+		}
+		levels = append(levels, plot.RiskLevel{Name: level.Name, Color: level.Color, Min: level.Min, Max: max})
+	}
+
+	return levels
+}
+
+type FileEntry struct {
+	Path       string   `json:"path"`
+	Complexity float64  `json:"complexity"`
+	Churn      float64  `json:"churn"`
+	Coverage   *float64 `json:"coverage,omitempty"`
+	Risk       float64  `json:"risk"`
+	RiskLevel  string   `json:"risk_level"`
+}
+
+type FunctionEntry struct {
+	Path       string  `json:"path"`
+	Name       string  `json:"name"`
+	Line       uint    `json:"line"`
+	Complexity uint    `json:"complexity"`
+	Churn      float64 `json:"churn"`
+	Risk       float64 `json:"risk"`
+}
+
+func (f FunctionEntry) key() string {
+	return f.Path + ":" + f.Name
+}
+
+// FunctionName joins package and name of function the same way as churn output
+func FunctionName(pkg []string, name string) string {
+	return strings.Join(append(append([]string{}, pkg...), name), "::")
+}
+
+// Sort orders entries by path, so snapshots of the same data are equal
+func (s *Snapshot) Sort() {
+	sort.Slice(s.Files, func(i, j int) bool { return s.Files[i].Path < s.Files[j].Path })
+	sort.Slice(s.Functions, func(i, j int) bool {
+		if s.Functions[i].Path != s.Functions[j].Path {
+			return s.Functions[i].Path < s.Functions[j].Path
+		}
+		return s.Functions[i].Line < s.Functions[j].Line
+	})
+}
+
+func Write(s *Snapshot, out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(s)
+}
+
+func Save(s *Snapshot, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return Write(s, f)
+}
+
+func Read(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+
+	if s.Version < 1 || s.Version > Version {
+		return nil, fmt.Errorf("unsupported snapshot version %d, supported versions are 1-%d", s.Version, Version)
+	}
+
+	return &s, nil
+}
+
+func Load(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return s, nil
+}
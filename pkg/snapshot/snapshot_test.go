@@ -0,0 +1,234 @@
+package snapshot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbvictor/ccv/pkg/plot"
+)
+
+func TestCompare(t *testing.T) {
+	opts := CompareOptions{ComplexityDelta: 0, ChurnDelta: 5, RiskDelta: 15, HotspotThreshold: 100}
+
+	tests := []struct {
+		name        string
+		base        []FileEntry
+		head        []FileEntry
+		baseFuncs   []FunctionEntry
+		headFuncs   []FunctionEntry
+		regressions []Regression
+		newHotspots []Hotspot
+		fixed       []Hotspot
+	}{
+		{
+			name: "empty snapshots",
+		},
+		{
+			name: "unchanged file",
+			base: []FileEntry{{Path: "a.go", Complexity: 3, Churn: 10, Risk: 30}},
+			head: []FileEntry{{Path: "a.go", Complexity: 3, Churn: 10, Risk: 30}},
+		},
+		{
+			name: "rise exactly at delta is not a regression",
+			base: []FileEntry{{Path: "a.go", Complexity: 3, Churn: 10, Risk: 30}},
+			head: []FileEntry{{Path: "a.go", Complexity: 3, Churn: 15, Risk: 45}},
+		},
+		{
+			name: "rise above delta is a regression",
+			base: []FileEntry{{Path: "a.go", Complexity: 3, Churn: 10, Risk: 30}},
+			head: []FileEntry{{Path: "a.go", Complexity: 4, Churn: 10, Risk: 40}},
+			regressions: []Regression{
+				{Path: "a.go", Metric: MetricComplexity, Old: 3, New: 4, Delta: 1},
+			},
+		},
+		{
+			name: "regressions are sorted by delta",
+			base: []FileEntry{{Path: "a.go", Complexity: 5, Churn: 10, Risk: 50}},
+			head: []FileEntry{{Path: "a.go", Complexity: 12, Churn: 20, Risk: 90}},
+			regressions: []Regression{
+				{Path: "a.go", Metric: MetricRisk, Old: 50, New: 90, Delta: 40},
+				{Path: "a.go", Metric: MetricChurn, Old: 10, New: 20, Delta: 10},
+				{Path: "a.go", Metric: MetricComplexity, Old: 5, New: 12, Delta: 7},
+			},
+		},
+		{
+			name:        "risk exactly at threshold becomes hotspot",
+			base:        []FileEntry{{Path: "a.go", Complexity: 10, Churn: 9, Risk: 90}},
+			head:        []FileEntry{{Path: "a.go", Complexity: 10, Churn: 10, Risk: 100, RiskLevel: "High"}},
+			newHotspots: []Hotspot{{Path: "a.go", Risk: 100, RiskLevel: "High"}},
+		},
+		{
+			name:  "risk below threshold fixes hotspot",
+			base:  []FileEntry{{Path: "a.go", Complexity: 20, Churn: 10, Risk: 200, RiskLevel: "High"}},
+			head:  []FileEntry{{Path: "a.go", Complexity: 5, Churn: 12, Risk: 60, RiskLevel: "Low"}},
+			fixed: []Hotspot{{Path: "a.go", Risk: 60, RiskLevel: "Low"}},
+		},
+		{
+			name:        "created file above threshold",
+			head:        []FileEntry{{Path: "a.go", Complexity: 25, Churn: 10, Risk: 250, RiskLevel: "High"}},
+			newHotspots: []Hotspot{{Path: "a.go", Risk: 250, RiskLevel: "High"}},
+		},
+		{
+			name: "created file below threshold",
+			head: []FileEntry{{Path: "a.go", Complexity: 2, Churn: 10, Risk: 20}},
+		},
+		{
+			name:  "deleted hotspot is fixed",
+			base:  []FileEntry{{Path: "a.go", Complexity: 30, Churn: 10, Risk: 300, RiskLevel: "High"}},
+			fixed: []Hotspot{{Path: "a.go", Risk: 300, RiskLevel: "High"}},
+		},
+		{
+			name: "deleted file below threshold",
+			base: []FileEntry{{Path: "a.go", Complexity: 2, Churn: 10, Risk: 20}},
+		},
+		{
+			// Snapshots do not know renames, metrics of renamed file are not compared
+			name:        "renamed hotspot",
+			base:        []FileEntry{{Path: "old.go", Complexity: 20, Churn: 10, Risk: 200, RiskLevel: "High"}},
+			head:        []FileEntry{{Path: "new.go", Complexity: 30, Churn: 10, Risk: 300, RiskLevel: "High"}},
+			newHotspots: []Hotspot{{Path: "new.go", Risk: 300, RiskLevel: "High"}},
+			fixed:       []Hotspot{{Path: "old.go", Risk: 200, RiskLevel: "High"}},
+		},
+		{
+			name:      "moved function is compared by name",
+			baseFuncs: []FunctionEntry{{Path: "a.go", Name: "Server::Handle", Line: 10, Complexity: 4, Churn: 2, Risk: 8}},
+			headFuncs: []FunctionEntry{{Path: "a.go", Name: "Server::Handle", Line: 25, Complexity: 9, Churn: 3, Risk: 27}},
+			regressions: []Regression{
+				{Path: "a.go", Metric: MetricRisk, Function: "Server::Handle", Old: 8, New: 27, Delta: 19},
+				{Path: "a.go", Metric: MetricComplexity, Function: "Server::Handle", Old: 4, New: 9, Delta: 5},
+			},
+		},
+		{
+			name:      "deleted function",
+			baseFuncs: []FunctionEntry{{Path: "a.go", Name: "Get", Line: 1, Complexity: 10, Churn: 10, Risk: 100}},
+		},
+		{
+			name:      "created function",
+			headFuncs: []FunctionEntry{{Path: "a.go", Name: "Get", Line: 1, Complexity: 10, Churn: 10, Risk: 100}},
+		},
+		{
+			// Overloads share name, only the first ones are compared
+			name: "overloaded function",
+			baseFuncs: []FunctionEntry{
+				{Path: "a.cpp", Name: "parse", Line: 1, Complexity: 2, Churn: 1, Risk: 2},
+				{Path: "a.cpp", Name: "parse", Line: 20, Complexity: 1, Churn: 1, Risk: 1},
+			},
+			headFuncs: []FunctionEntry{
+				{Path: "a.cpp", Name: "parse", Line: 1, Complexity: 3, Churn: 1, Risk: 3},
+				{Path: "a.cpp", Name: "parse", Line: 20, Complexity: 10, Churn: 1, Risk: 10},
+			},
+			regressions: []Regression{
+				{Path: "a.cpp", Metric: MetricComplexity, Function: "parse", Old: 2, New: 3, Delta: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &Snapshot{Version: Version, Commit: "aaaa", Files: tt.base, Functions: tt.baseFuncs}
+			head := &Snapshot{Version: Version, Commit: "bbbb", Files: tt.head, Functions: tt.headFuncs}
+
+			diff := Compare(base, head, opts)
+
+			assert.Equal(t, "aaaa", diff.Old.Commit)
+			assert.Equal(t, "bbbb", diff.New.Commit)
+			assert.Equal(t, append([]Regression{}, tt.regressions...), diff.Regressions)
+			assert.Equal(t, append([]Hotspot{}, tt.newHotspots...), diff.NewHotspots)
+			assert.Equal(t, append([]Hotspot{}, tt.fixed...), diff.FixedHotspots)
+		})
+	}
+}
+
+func TestCompareSortsHotspots(t *testing.T) {
+	base := &Snapshot{Version: Version}
+	head := &Snapshot{Version: Version, Files: []FileEntry{
+		{Path: "a.go", Risk: 150},
+		{Path: "b.go", Risk: 250},
+	}}
+
+	diff := Compare(base, head, CompareOptions{HotspotThreshold: 100})
+	assert.Equal(t, []Hotspot{{Path: "b.go", Risk: 250}, {Path: "a.go", Risk: 150}}, diff.NewHotspots)
+}
+
+func TestReadVersion(t *testing.T) {
+	snap := &Snapshot{Version: Version, Commit: "aaaa", Files: []FileEntry{{Path: "a.go", Complexity: 3, Churn: 10, Risk: 30}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(snap, &buf))
+
+	got, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, snap.Files, got.Files)
+
+	_, err = Read(strings.NewReader(`{"version": 99}`))
+	assert.ErrorContains(t, err, "unsupported snapshot version 99")
+
+	_, err = Read(strings.NewReader(`{"files": []}`))
+	assert.Error(t, err)
+}
+
+func TestPrintDiffMarkdown(t *testing.T) {
+	base := &Snapshot{Version: Version, Files: []FileEntry{
+		{Path: "growing.go", Complexity: 5, Churn: 10, Risk: 50},
+		{Path: "deleted.go", Complexity: 30, Churn: 10, Risk: 300, RiskLevel: "High"},
+	}}
+	head := &Snapshot{Version: Version, Files: []FileEntry{
+		{Path: "growing.go", Complexity: 12, Churn: 20, Risk: 240, RiskLevel: "High"},
+	}}
+	diff := Compare(base, head, CompareOptions{ComplexityDelta: 5, ChurnDelta: 100, RiskDelta: 1000, HotspotThreshold: 260})
+
+	var buf bytes.Buffer
+	require.NoError(t, PrintDiff(diff, &buf, Markdown))
+
+	expected := "## Complexity and churn changes\n\n" +
+		"### Regressions (1)\n\n" +
+		"| Metric | Old | New | Delta | Location |\n" +
+		"|---|---:|---:|---:|---|\n" +
+		"| complexity | 5.00 | 12.00 | +7.00 | `growing.go` |\n\n" +
+		"### New hotspots (0)\n\n" +
+		"### Fixed hotspots (1)\n\n" +
+		"| Risk score | Risk level | File |\n" +
+		"|---:|---|---|\n" +
+		"| 300.00 | High | `deleted.go` |\n\n"
+
+	assert.Equal(t, expected, buf.String())
+
+	assert.Error(t, PrintDiff(diff, &buf, "xml"))
+}
+
+func TestSnapshotLevels(t *testing.T) {
+	sum := plot.RiskOptions{Formula: plot.FormulaSum, Normalization: plot.NormalizeNone, ComplexityWeight: 1, ChurnWeight: 1}
+	product := plot.DefaultRiskLevels(plot.RiskOptions{Formula: plot.FormulaProduct, Normalization: plot.NormalizeNone})
+
+	tests := []struct {
+		name     string
+		risk     *RiskSettings
+		hotspots int
+	}{
+		// Default levels depend on formula, so levels of sum formula are kept in snapshot
+		{name: "levels of snapshot formula", risk: NewRiskSettings(sum, plot.DefaultRiskLevels(sum)), hotspots: 1},
+		{name: "version 1 without settings", risk: nil, hotspots: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &Snapshot{Version: Version, Risk: tt.risk, Files: []FileEntry{{Path: "main.go", Risk: 26.25}}}
+			head := &Snapshot{Version: Version, Risk: tt.risk, Files: []FileEntry{{Path: "main.go", Risk: 39.54}}}
+
+			// Levels survive saving, unbounded top level has no max in JSON
+			var buf bytes.Buffer
+			require.NoError(t, Write(head, &buf))
+			head, err := Read(&buf)
+			require.NoError(t, err)
+
+			threshold, err := plot.LevelThreshold(head.Levels(product), "Medium Risk")
+			require.NoError(t, err)
+
+			diff := Compare(base, head, CompareOptions{HotspotThreshold: threshold})
+			assert.Len(t, diff.NewHotspots, tt.hotspots)
+		})
+	}
+}